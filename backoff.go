@@ -0,0 +1,94 @@
+package kail
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	backoffMin = 500 * time.Millisecond
+	backoffMax = 30 * time.Second
+)
+
+// backoff computes exponentially increasing delays between attempts,
+// with jitter so that streams failing together do not retry together.
+type backoff struct {
+	min     time.Duration
+	max     time.Duration
+	attempt int
+
+	// time of the last attempt.  the delays start over once
+	// there have been no attempts for twice the maximum delay.
+	last time.Time
+}
+
+func newBackoff() *backoff {
+	return &backoff{min: backoffMin, max: backoffMax}
+}
+
+// next returns the delay before the next attempt, made as of now.
+// The delay is between half and all of min doubled for each
+// previous attempt, up to max.
+func (b *backoff) next(now time.Time) time.Duration {
+	if !b.last.IsZero() && now.Sub(b.last) > 2*b.max {
+		b.attempt = 0
+	}
+
+	delay := b.max
+	if b.attempt < 32 && b.min<<uint(b.attempt) < b.max {
+		delay = b.min << uint(b.attempt)
+	}
+
+	b.attempt++
+	b.last = now
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+	b.last = time.Time{}
+}
+
+// isTransientError reports whether reading logs failed for a reason
+// that is likely to pass, such as a timeout, a dropped connection or
+// a server error, and so should be retried.
+func isTransientError(err error) bool {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		switch code := status.Status().Code; {
+		case code >= 500:
+			return true
+		case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), apierrors.IsTooManyRequests(err):
+			return true
+		default:
+			return false
+		}
+	}
+
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// isPermanentError reports whether reading logs failed for a reason
+// that recreating the stream will not fix: access is denied or the
+// pod is gone.
+func isPermanentError(err error) bool {
+	return apierrors.IsForbidden(err) ||
+		apierrors.IsUnauthorized(err) ||
+		apierrors.IsNotFound(err) ||
+		apierrors.IsGone(err)
+}
@@ -0,0 +1,61 @@
+package kail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{min: time.Second, max: 10 * time.Second}
+	now := time.Now()
+
+	for _, max := range []time.Duration{1, 2, 4, 8, 10, 10} {
+		delay := b.next(now)
+		assert.True(t, delay >= max*time.Second/2, "%v >= %v", delay, max*time.Second/2)
+		assert.True(t, delay <= max*time.Second, "%v <= %v", delay, max*time.Second)
+	}
+
+	// starts over after a quiet period.
+	assert.True(t, b.next(now.Add(time.Minute)) <= time.Second)
+
+	b.reset()
+	assert.True(t, b.next(now) <= time.Second)
+}
+
+func TestErrorKinds(t *testing.T) {
+	resource := schema.GroupResource{Resource: "pods"}
+
+	transient := []error{
+		apierrors.NewInternalError(errors.New("boom")),
+		apierrors.NewServiceUnavailable("unavailable"),
+		apierrors.NewTooManyRequests("slow down", 1),
+		apierrors.NewTimeoutError("timeout", 1),
+		io.ErrUnexpectedEOF,
+		fmt.Errorf("read: %w", syscall.ECONNRESET),
+	}
+	for _, err := range transient {
+		assert.True(t, isTransientError(err), "%v", err)
+		assert.False(t, isPermanentError(err), "%v", err)
+	}
+
+	permanent := []error{
+		apierrors.NewForbidden(resource, "pod", errors.New("denied")),
+		apierrors.NewNotFound(resource, "pod"),
+	}
+	for _, err := range permanent {
+		assert.False(t, isTransientError(err), "%v", err)
+		assert.True(t, isPermanentError(err), "%v", err)
+	}
+
+	err := apierrors.NewBadRequest("container app is waiting to start")
+	assert.False(t, isTransientError(err))
+	assert.False(t, isPermanentError(err))
+}
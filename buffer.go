@@ -1,8 +1,9 @@
 package kail
 
-import "bytes"
-
-const bufferMaxRetainSize = logBufsiz
+import (
+	"bytes"
+	"time"
+)
 
 type buffer interface {
 	process([]byte) []Event
@@ -11,42 +12,150 @@ type buffer interface {
 type _buffer struct {
 	source EventSource
 	prev   *bytes.Buffer
+
+	// lines longer than maxSize are split into partial events
+	// of maxSize, or truncated to maxSize if truncate is set.
+	maxSize  int
+	truncate bool
+
+	// timestamp of the most recent line.  lines that were
+	// split because they exceeded maxSize only carry
+	// a timestamp on their first segment.
+	last  time.Time
+	split bool
+
+	// drop the remainder of a truncated line.
+	discard bool
 }
 
-func newBuffer(source EventSource) buffer {
-	return &_buffer{source, new(bytes.Buffer)}
+func newBuffer(source EventSource, maxSize int, truncate bool) buffer {
+	return &_buffer{
+		source:   source,
+		prev:     new(bytes.Buffer),
+		maxSize:  maxSize,
+		truncate: truncate,
+	}
 }
 
 func (b *_buffer) process(log []byte) []Event {
 
 	var events []Event
 
-	for end := bytes.IndexRune(log, '\n'); end >= 0 && len(log) > 0; end = bytes.IndexRune(log, '\n') {
-		var ebuf []byte
+	for len(log) > 0 {
+		end := bytes.IndexByte(log, '\n')
 
-		if plen := b.prev.Len(); plen > 0 {
-			ebuf = make([]byte, plen+end)
-			copy(ebuf, b.prev.Bytes())
-			copy(ebuf[plen:], log[:end])
-			b.prev.Reset()
-		} else {
-			ebuf = make([]byte, end)
-			copy(ebuf, log[:end])
+		if end < 0 {
+			if !b.discard {
+				b.prev.Write(log)
+				events = b.take(events, false)
+			}
+			break
+		}
+
+		if !b.discard {
+			b.prev.Write(log[:end])
+			events = b.take(events, true)
 		}
 
-		events = append(events, newEvent(b.source, ebuf))
+		b.discard = false
 		log = log[end+1:]
 	}
 
-	if sz := len(log); sz > 0 {
-		b.prev.Write(log)
-		if plen := b.prev.Len(); plen >= bufferMaxRetainSize {
-			ebuf := make([]byte, plen)
-			copy(ebuf, b.prev.Bytes())
-			events = append(events, newEvent(b.source, ebuf))
+	return events
+}
+
+// take returns events for the retained data, splitting or truncating
+// it at maxSize.  if the line is not complete, data that fits within
+// maxSize is retained until more is read.
+func (b *_buffer) take(events []Event, complete bool) []Event {
+	data := b.prev.Bytes()
+
+	// the timestamp of the first segment is not counted.
+	limit := b.maxSize
+	if !b.split {
+		limit += timestampLen(data)
+	}
+
+	for len(data) > limit {
+		events = append(events, b.newEvent(copyBytes(data[:limit]), false))
+		data = data[limit:]
+		limit = b.maxSize
+
+		if b.truncate {
 			b.prev.Reset()
+			b.split = false
+			b.discard = !complete
+			return events
 		}
 	}
 
+	switch {
+	case !complete:
+		rest := copyBytes(data)
+		b.prev.Reset()
+		b.prev.Write(rest)
+	case len(data) == 0 && b.split:
+		// the line ended at the end of its last segment.
+		b.prev.Reset()
+		b.split = false
+	default:
+		events = append(events, b.newEvent(copyBytes(data), true))
+		b.prev.Reset()
+	}
+
 	return events
 }
+
+func (b *_buffer) newEvent(log []byte, complete bool) Event {
+	partial := b.split || !complete
+	if !b.split {
+		b.last, log = parseTimestamp(log)
+	}
+	b.split = !complete
+	if partial {
+		return newPartialEvent(b.source, log, b.last)
+	}
+	return newEvent(b.source, log, b.last)
+}
+
+func copyBytes(data []byte) []byte {
+	buf := make([]byte, len(data))
+	copy(buf, data)
+	return buf
+}
+
+// timestampLen returns the length of the timestamp
+// prefixing log, including the following space.
+func timestampLen(log []byte) int {
+	if len(log) > len(time.RFC3339Nano)+1 {
+		log = log[:len(time.RFC3339Nano)+1]
+	}
+	idx := bytes.IndexByte(log, ' ')
+	if idx < 0 {
+		return 0
+	}
+	if _, err := time.Parse(time.RFC3339Nano, string(log[:idx])); err != nil {
+		return 0
+	}
+	return idx + 1
+}
+
+// parseTimestamp strips the RFC3339 timestamp that kubelet prefixes
+// each line with when timestamps are requested.  The log is returned
+// unchanged with a zero time if no timestamp is present.
+func parseTimestamp(log []byte) (time.Time, []byte) {
+	idx := bytes.IndexByte(log, ' ')
+	if idx < 0 {
+		idx = len(log)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, string(log[:idx]))
+	if err != nil {
+		return time.Time{}, log
+	}
+
+	if idx < len(log) {
+		idx++
+	}
+	return t, log[idx:]
+}
@@ -2,6 +2,7 @@ package kail
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -10,7 +11,7 @@ func TestBuffer(t *testing.T) {
 	source := eventSource{}
 
 	{
-		buffer := newBuffer(source)
+		buffer := newBuffer(source, logMaxLineSize, false)
 		events := buffer.process([]byte(""))
 		assert.Empty(t, events)
 
@@ -20,7 +21,7 @@ func TestBuffer(t *testing.T) {
 	}
 
 	{
-		buffer := newBuffer(source)
+		buffer := newBuffer(source, logMaxLineSize, false)
 		events := buffer.process([]byte("foo"))
 		assert.Empty(t, events)
 
@@ -30,7 +31,7 @@ func TestBuffer(t *testing.T) {
 	}
 
 	{
-		buffer := newBuffer(source)
+		buffer := newBuffer(source, logMaxLineSize, false)
 		events := buffer.process([]byte("foo\n"))
 		assert.Len(t, events, 1)
 		assert.Equal(t, "foo", string(events[0].Log()))
@@ -41,7 +42,7 @@ func TestBuffer(t *testing.T) {
 	}
 
 	{
-		buffer := newBuffer(source)
+		buffer := newBuffer(source, logMaxLineSize, false)
 		events := buffer.process([]byte("foo\nbar\n"))
 		assert.Len(t, events, 2)
 		assert.Equal(t, "foo", string(events[0].Log()))
@@ -53,7 +54,7 @@ func TestBuffer(t *testing.T) {
 	}
 
 	{
-		buffer := newBuffer(source)
+		buffer := newBuffer(source, logMaxLineSize, false)
 		events := buffer.process([]byte("foo\nbar"))
 		assert.Len(t, events, 1)
 		assert.Equal(t, "foo", string(events[0].Log()))
@@ -63,4 +64,49 @@ func TestBuffer(t *testing.T) {
 		assert.Equal(t, "barbaz", string(events[0].Log()))
 	}
 
+	{
+		buffer := newBuffer(source, logMaxLineSize, false)
+		events := buffer.process([]byte("2024-01-02T03:04:05.123456789Z foo\n2024-01-02T03:04:06Z \n"))
+		assert.Len(t, events, 2)
+		assert.Equal(t, "foo", string(events[0].Log()))
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC), events[0].Time())
+		assert.Equal(t, "", string(events[1].Log()))
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC), events[1].Time())
+
+		events = buffer.process([]byte("not-a-timestamp bar\n"))
+		assert.Len(t, events, 1)
+		assert.Equal(t, "not-a-timestamp bar", string(events[0].Log()))
+		assert.True(t, events[0].Time().IsZero())
+	}
+
+	{
+		buffer := newBuffer(source, 4, false)
+		events := buffer.process([]byte("2024-01-02T03:04:05Z abcdefghij"))
+		assert.Len(t, events, 2)
+		assert.Equal(t, "abcd", string(events[0].Log()))
+		assert.Equal(t, "efgh", string(events[1].Log()))
+
+		events = buffer.process([]byte("\nfoo\n"))
+		assert.Len(t, events, 2)
+		assert.Equal(t, "ij", string(events[0].Log()))
+		assert.Equal(t, "foo", string(events[1].Log()))
+		assert.True(t, events[0].Partial())
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), events[0].Time())
+		assert.False(t, events[1].Partial())
+	}
+
+	{
+		buffer := newBuffer(source, 4, true)
+		events := buffer.process([]byte("abcdefghij"))
+		assert.Len(t, events, 1)
+		assert.Equal(t, "abcd", string(events[0].Log()))
+		assert.True(t, events[0].Partial())
+
+		events = buffer.process([]byte("klmn\nfoo\nbarbazqux\n"))
+		assert.Len(t, events, 2)
+		assert.Equal(t, "foo", string(events[0].Log()))
+		assert.False(t, events[0].Partial())
+		assert.Equal(t, "barb", string(events[1].Log()))
+		assert.True(t, events[1].Partial())
+	}
 }
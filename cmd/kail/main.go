@@ -8,6 +8,7 @@ import (
 	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	logutil "github.com/boz/go-logutil"
 	logutil_logrus "github.com/boz/go-logutil/logrus"
@@ -52,12 +53,115 @@ var (
 
 	flagContext = kingpin.Flag("context", "kubernetes context").PlaceHolder("CONTEXT-NAME").String()
 
+	flagQPS = kingpin.Flag("qps", "maximum queries per second to the kubernetes API, including opening log streams").
+		PlaceHolder("QPS").
+		Default("50").
+		Float32()
+	flagBurst = kingpin.Flag("burst", "maximum burst of queries to the kubernetes API").
+			PlaceHolder("N").
+			Default("100").
+			Int()
+
 	flagCurrentNS = kingpin.Flag("current-ns", "use namespace from current context").
 			Default("false").
 			Bool()
 
 	flagContainers = kingpin.Flag("containers", "containers").Short('c').PlaceHolder("NAME").Strings()
 
+	flagGrep       = kingpin.Flag("grep", "only display lines matching the given regex").PlaceHolder("REGEX").Strings()
+	flagGrepV      = kingpin.Flag("grep-v", "do not display lines matching the given regex").PlaceHolder("REGEX").Strings()
+	flagIgnoreCase = kingpin.Flag("ignore-case", "ignore case when matching --grep, --grep-v, --until and --exit-on-pattern patterns").
+			Short('i').
+			Default("false").
+			Bool()
+	flagAfterContext = kingpin.Flag("after-context", "display N lines after each line matching --grep").
+				Short('A').
+				PlaceHolder("N").
+				Int()
+	flagBeforeContext = kingpin.Flag("before-context", "display N lines before each line matching --grep").
+				Short('B').
+				PlaceHolder("N").
+				Int()
+	flagGrepContext = kingpin.Flag("grep-context", "display N lines before and after each line matching --grep").
+			Short('C').
+			PlaceHolder("N").
+			Int()
+
+	flagMeta = kingpin.Flag("meta", "display events such as containers starting, terminating and restarting, and pods being deleted").
+			Default("false").
+			Bool()
+
+	flagEvents = kingpin.Flag("events", "display kubernetes events involving the selected pods, such as scheduling failures and probe failures").
+			Default("false").
+			Bool()
+
+	flagMaxStreams = kingpin.Flag("max-streams", "maximum number of containers whose logs are streamed at once, or 0 for no limit").
+			PlaceHolder("N").
+			Default("0").
+			Int()
+	flagPrefer = kingpin.Flag("prefer", "stream containers of pods matching the given selector first when waiting for --max-streams").
+			PlaceHolder("SELECTOR").
+			Strings()
+	flagNewestFirst = kingpin.Flag("newest-first", "stream containers of the most recently created pods first when waiting for --max-streams").
+			Default("false").
+			Bool()
+
+	flagFailFast = kingpin.Flag("fail-fast", "exit as soon as reading the logs of any container fails").
+			Default("false").
+			Bool()
+
+	flagDelivery = kingpin.Flag("delivery", "when output can not keep up: drop lines, drop lines and notify, or block reading logs (drop, notify, block)").
+			Default("drop").
+			Enum("drop", "notify", "block")
+	flagEventBuffer = kingpin.Flag("event-buffer", "number of lines buffered for output").
+			PlaceHolder("N").
+			Default("500").
+			Int()
+
+	flagMaxLineSize = kingpin.Flag("max-line-size", "split log lines longer than SIZE into multiple lines marked partial, like 512KB or 4MB").
+			PlaceHolder("SIZE").
+			Default("1MB").
+			Bytes()
+	flagTruncateLines = kingpin.Flag("truncate-lines", "truncate log lines longer than --max-line-size instead of splitting them").
+				Default("false").
+				Bool()
+	flagReadBufferSize = kingpin.Flag("read-buffer-size", "size of the buffer each container's logs are read into").
+				PlaceHolder("SIZE").
+				Default("16KB").
+				Bytes()
+
+	flagJoinIndented = kingpin.Flag("join-indented", "join lines starting with whitespace, such as stack traces, to the preceding line").
+				Default("false").
+				Bool()
+	flagJoinStart = kingpin.Flag("join-start", "join lines not matching the given regex, which starts each record, to the preceding line").
+			PlaceHolder("REGEX").
+			String()
+	flagJoinTimeout = kingpin.Flag("join-timeout", "display a record joined by --join-indented or --join-start once no lines have arrived for DURATION").
+			PlaceHolder("DURATION").
+			Default("250ms").
+			Duration()
+
+	flagMinLevel = kingpin.Flag("min-level", "only display structured lines logged at LEVEL or above (trace, debug, info, warn, error, fatal)").
+			PlaceHolder("LEVEL").
+			Enum("trace", "debug", "info", "warn", "error", "fatal")
+	flagDropUnleveled = kingpin.Flag("drop-unleveled", "do not display lines without a level when --min-level is given").
+				Default("false").
+				Bool()
+
+	flagWhere = kingpin.Flag("where", "only display structured lines matching the given expression, like 'status>=500 && path=~\"^/api\"'").
+			PlaceHolder("EXPR").
+			Strings()
+
+	flagUntil = kingpin.Flag("until", "exit once a line matching the given regex is displayed; see --ignore-case").
+			PlaceHolder("REGEX").
+			String()
+	flagTimeout = kingpin.Flag("timeout", "exit with an error if no line matches --until within DURATION").
+			PlaceHolder("DURATION").
+			Duration()
+	flagExitOnPattern = kingpin.Flag("exit-on-pattern", "exit with an error once a line matching the given regex is displayed; see --ignore-case").
+				PlaceHolder("REGEX").
+				Strings()
+
 	flagDryRun = kingpin.Flag("dry-run", "print matching pods and exit").
 			Default("false").
 			Bool()
@@ -69,17 +173,88 @@ var (
 			Default("error").
 			Enum("debug", "info", "warn", "error")
 
-	flagSince = kingpin.Flag("since", "Display logs generated since given duration, like 5s, 2m, 1.5h or 2h45m. Defaults to 1s.").
+	flagSince = kingpin.Flag("since", "Display logs generated since given duration, like 5s, 2m, 1.5h or 2h45m. Defaults to 1s unless --tail is given.").
+			PlaceHolder("DURATION").
+			Duration()
+
+	flagTail = kingpin.Flag("tail", "Display the last N lines of each container's logs, limited by --since if given.").
+			PlaceHolder("N").
+			Default("-1").
+			Int64()
+
+	flagNoFollow = kingpin.Flag("no-follow", "display existing logs of running containers and exit").
+			Default("false").
+			Bool()
+
+	flagPrevious = kingpin.Flag("previous", "display logs of the previous instance of restarted containers and exit").
+			Default("false").
+			Bool()
+
+	flagPreviousOnRestart = kingpin.Flag("previous-on-restart", "display logs of the previous instance of containers as they restart").
+				Default("false").
+				Bool()
+
+	flagPreviousTail = kingpin.Flag("previous-tail", "number of lines of the previous instance to display with --previous-on-restart, or -1 for all").
+				PlaceHolder("N").
+				Default("50").
+				Int64()
+
+	flagReorder = kingpin.Flag("reorder", "hold lines for DURATION and display them in the order they were logged across containers, like 500ms").
 			PlaceHolder("DURATION").
-			Default("1s").
 			Duration()
 
-	flagOutput = kingpin.Flag("output", "Log output mode (default, raw, json, or json-pretty, zerolog)").
+	flagOutput = kingpin.Flag("output", "Log output mode (default, raw, json, json-pretty, logfmt, pretty, or zerolog)").
 			Short('o').
 			PlaceHolder("default").
 			Default("default").
 			String()
 
+	flagTimestamps = kingpin.Flag("timestamps", "Prefix each line with the time it was logged, works with --output=default and --output=zerolog").
+			Default("false").
+			Bool()
+
+	flagTemplate = kingpin.Flag("template", "format each line with the given go template, overrides --output").
+			PlaceHolder("TEMPLATE").
+			String()
+
+	flagPrefixTemplate = kingpin.Flag("prefix-template", "format the line prefix with the given go template, works with --output=default and --output=zerolog").
+				PlaceHolder("TEMPLATE").
+				String()
+
+	flagColor = kingpin.Flag("color", "color output (auto, always, never)").
+			Default("auto").
+			Enum("auto", "always", "never")
+
+	flagColorBy = kingpin.Flag("color-by", "choose line prefix colors by pod, container, namespace, node, or none").
+			Default("pod").
+			Enum("pod", "container", "namespace", "node", "none")
+
+	flagLevelKey = kingpin.Flag("level-key", "field holding the level of structured log lines, only used by --output=pretty").
+			PlaceHolder("KEY").
+			Strings()
+	flagTimeKey = kingpin.Flag("time-key", "field holding the time of structured log lines, works with --output=pretty").
+			PlaceHolder("KEY").
+			Strings()
+	flagMessageKey = kingpin.Flag("message-key", "field holding the message of structured log lines, works with --output=pretty").
+			PlaceHolder("KEY").
+			Strings()
+	flagErrorKey = kingpin.Flag("error-key", "field holding the error of structured log lines, works with --output=pretty").
+			PlaceHolder("KEY").
+			Strings()
+
+	flagFields = kingpin.Flag("fields", "limit json output to the given comma separated fields, like 'namespace, message.req.id as reqid', works with --output=json and --output=json-pretty").
+			PlaceHolder("FIELDS").
+			String()
+	flagFlatten = kingpin.Flag("flatten", "flatten nested objects into dotted keys, works with --output=json and --output=json-pretty").
+			Default("false").
+			Bool()
+	flagIncludeNode = kingpin.Flag("include-node", "include the node of each pod, works with --output=json and --output=json-pretty").
+			Default("false").
+			Bool()
+	flagIncludeLabels = kingpin.Flag("include-labels", "include the labels of each pod, works with --output=json and --output=json-pretty").
+				Default("false").
+				Bool()
+
 	flagZerologTimestampFieldName = kingpin.Flag("zerolog-timestamp-field", "sets the zerolog timestamp field name, works with --output=zerolog").
 					Default("time").
 					String()
@@ -125,7 +300,7 @@ func main() {
 
 	ctx, cancel := context.WithCancel(ctx)
 
-	cs, rc := createKubeClient(ctx)
+	cs := createKubeClient(ctx)
 
 	sigch := watchSignals(ctx, cancel)
 
@@ -133,19 +308,91 @@ func main() {
 
 	filter := kail.NewContainerFilter(*flagContainers)
 
+	var summary kail.Summary
+	var result matchResult
+
 	if *flagDryRun {
 
 		listPods(ds, filter)
 
 	} else {
 
-		streamLogs(createController(ctx, cs, rc, ds, filter))
+		controller := createController(ctx, cs, ds, filter)
+		result = streamLogs(controller)
+		summary = controller.Summary()
 
 	}
 
 	cancel()
 	<-ds.Done()
-	<-sigch
+	interrupted := <-sigch
+
+	if !*flagDryRun {
+		os.Exit(exitStatus(summary, interrupted, result))
+	}
+}
+
+// exit statuses, in addition to 1 for errors starting up.
+const (
+	exitNoSources      = 2
+	exitAllFailed      = 3
+	exitPartialFailure = 4
+	exitPatternMatched = 5
+	exitNotMatched     = 6
+	exitInterrupted    = 130
+)
+
+// matchResult is the outcome of matching lines
+// against --until and --exit-on-pattern.
+type matchResult int
+
+const (
+	matchNone matchResult = iota
+	matchUntil
+	matchExit
+	matchTimeout
+)
+
+// exitStatus reports on the containers streamed and
+// returns the status that kail should exit with.
+func exitStatus(summary kail.Summary, interrupted bool, result matchResult) int {
+	for _, failure := range summary.Failures {
+		fmt.Fprintf(os.Stderr, "kail: %v\n", failure)
+	}
+
+	status, reason := exitCode(summary, interrupted, result, *flagUntil != "", *flagTimeout)
+	if reason != "" {
+		fmt.Fprintf(os.Stderr, "kail: %v\n", reason)
+	}
+	return status
+}
+
+// exitCode returns the status that kail should exit with, and why.  A
+// match takes precedence over failures, which take precedence over being
+// interrupted.  until is set if lines were matched against --until.
+func exitCode(summary kail.Summary,
+	interrupted bool, result matchResult, until bool, timeout time.Duration) (int, string) {
+
+	switch failed := len(summary.Failures); {
+	case result == matchExit:
+		return exitPatternMatched, "line matched --exit-on-pattern"
+	case result == matchUntil:
+		return 0, ""
+	case result == matchTimeout:
+		return exitNotMatched, fmt.Sprintf("no line matched --until within %v", timeout)
+	case failed > 0 && failed >= summary.Sources:
+		return exitAllFailed, fmt.Sprintf("all %v containers failed", failed)
+	case failed > 0:
+		return exitPartialFailure, fmt.Sprintf("%v of %v containers failed", failed, summary.Sources)
+	case interrupted:
+		return exitInterrupted, ""
+	case until:
+		return exitNotMatched, "no line matched --until"
+	case summary.Sources == 0:
+		return exitNoSources, "no containers matched"
+	default:
+		return 0, ""
+	}
 }
 
 func showVersion() {
@@ -153,8 +400,10 @@ func showVersion() {
 	return
 }
 
-func watchSignals(ctx context.Context, cancel context.CancelFunc) <-chan struct{} {
-	donech := make(chan struct{})
+// watchSignals cancels ctx when interrupted.  The returned channel
+// receives whether kail was interrupted once ctx is done.
+func watchSignals(ctx context.Context, cancel context.CancelFunc) <-chan bool {
+	donech := make(chan bool, 1)
 	sigch := make(chan os.Signal, 1)
 	signal.Notify(sigch, syscall.SIGINT, syscall.SIGHUP)
 	go func() {
@@ -164,6 +413,7 @@ func watchSignals(ctx context.Context, cancel context.CancelFunc) <-chan struct{
 		case <-ctx.Done():
 		case <-sigch:
 			cancel()
+			donech <- true
 		}
 	}()
 	return donech
@@ -187,14 +437,15 @@ func createLog() logutil.Log {
 	return logutil_logrus.New(parent).WithComponent("kail.main")
 }
 
-func createKubeClient(ctx context.Context) (kubernetes.Interface, *rest.Config) {
+func createKubeClient(ctx context.Context) kubernetes.Interface {
 
 	config, err := rest.InClusterConfig()
 	switch {
 	case err == nil:
+		configureRateLimits(config)
 		cs, err := kubernetes.NewForConfig(config)
 		kingpin.FatalIfError(err, "Error configuring kubernetes connection")
-		return cs, config
+		return cs
 	case config != nil:
 		kingpin.Fatalf("Error configuring in-cluster config: %v", err)
 	}
@@ -216,6 +467,8 @@ func createKubeClient(ctx context.Context) (kubernetes.Interface, *rest.Config)
 	rc, err := cc.ClientConfig()
 	kingpin.FatalIfError(err, "Error determining client config")
 
+	configureRateLimits(rc)
+
 	cs, err := kubernetes.NewForConfig(rc)
 	kingpin.FatalIfError(err, "Error building kubernetes config")
 
@@ -224,7 +477,14 @@ func createKubeClient(ctx context.Context) (kubernetes.Interface, *rest.Config)
 		kingpin.FatalIfError(err, "Can't connnect to kubernetes")
 	}
 
-	return cs, rc
+	return cs
+}
+
+// configureRateLimits applies --qps and --burst.  A single client is shared
+// by all log streams, so these bound how quickly streams are opened.
+func configureRateLimits(config *rest.Config) {
+	config.QPS = *flagQPS
+	config.Burst = *flagBurst
 }
 
 func createDSBuilder() kail.DSBuilder {
@@ -297,6 +557,10 @@ func createDSBuilder() kail.DSBuilder {
 		dsb = dsb.WithRegex(*flagRegex)
 	}
 
+	if *flagEvents {
+		dsb = dsb.WithEvents()
+	}
+
 	return dsb
 }
 
@@ -331,46 +595,274 @@ func listPods(ds kail.DS, filter kail.ContainerFilter) {
 }
 
 func createController(
-	ctx context.Context, cs kubernetes.Interface, rc *rest.Config, ds kail.DS, filter kail.ContainerFilter) kail.Controller {
+	ctx context.Context, cs kubernetes.Interface, ds kail.DS, filter kail.ContainerFilter) kail.Controller {
+
+	since := *flagSince
+	if since == 0 && *flagTail < 0 && !*flagPrevious {
+		since = time.Second
+	}
 
-	controller, err := kail.NewController(ctx, cs, rc, ds.Pods(), filter, *flagSince)
+	opts := createControllerOptions()
+	if *flagEvents {
+		opts = append(opts, kail.WithKubernetesEvents(ds.Events()))
+	}
+
+	controller, err := kail.NewController(ctx, cs, ds.Pods(), filter, since, opts...)
 	kingpin.FatalIfError(err, "Error creating controller")
 
 	return controller
 }
 
-func streamLogs(controller kail.Controller) {
+func createControllerOptions() []kail.ControllerOption {
+	var opts []kail.ControllerOption
+
+	if *flagNoFollow {
+		opts = append(opts, kail.WithFollow(false))
+	}
+
+	if *flagTail >= 0 {
+		opts = append(opts, kail.WithTail(*flagTail))
+	}
+
+	if *flagPrevious {
+		opts = append(opts, kail.WithPrevious())
+	}
+
+	if *flagPreviousOnRestart {
+		opts = append(opts, kail.WithPreviousOnRestart(*flagPreviousTail))
+	}
+
+	if *flagReorder > 0 {
+		opts = append(opts, kail.WithReorder(*flagReorder))
+	}
+
+	if *flagMeta {
+		opts = append(opts, kail.WithMetaEvents())
+	}
+
+	if *flagMaxStreams < 0 {
+		kingpin.Fatalf("--max-streams must not be negative")
+	}
+	if *flagMaxStreams > 0 {
+		opts = append(opts, kail.WithMaxStreams(*flagMaxStreams))
+	}
+
+	if prefer := parseLabels("prefer", *flagPrefer); len(prefer) > 0 {
+		opts = append(opts, kail.WithPreferredPods(prefer...))
+	}
+
+	if *flagNewestFirst {
+		opts = append(opts, kail.WithNewestFirst())
+	}
+
+	if *flagFailFast {
+		opts = append(opts, kail.WithFailFast())
+	}
+
+	switch *flagDelivery {
+	case "notify":
+		opts = append(opts, kail.WithDelivery(kail.DeliveryNotify))
+	case "block":
+		opts = append(opts, kail.WithDelivery(kail.DeliveryBlock))
+	}
+
+	if *flagEventBuffer <= 0 {
+		kingpin.Fatalf("--event-buffer must be positive")
+	}
+	opts = append(opts, kail.WithEventBufferSize(*flagEventBuffer))
+
+	if *flagMaxLineSize <= 0 || *flagReadBufferSize <= 0 {
+		kingpin.Fatalf("--max-line-size and --read-buffer-size must be positive")
+	}
+	opts = append(opts,
+		kail.WithMaxLineSize(int(*flagMaxLineSize), *flagTruncateLines),
+		kail.WithReadBufferSize(int(*flagReadBufferSize)))
+
+	switch {
+	case *flagJoinIndented && *flagJoinStart != "":
+		kingpin.Fatalf("--join-indented and --join-start can not be combined")
+	case *flagJoinIndented:
+		opts = append(opts, kail.WithLineJoiner(kail.NewIndentJoiner(), *flagJoinTimeout))
+	case *flagJoinStart != "":
+		joiner, err := kail.NewRecordStartJoiner(*flagJoinStart)
+		kingpin.FatalIfError(err, "invalid --join-start expression")
+		opts = append(opts, kail.WithLineJoiner(joiner, *flagJoinTimeout))
+	}
+
+	if len(*flagGrep) > 0 || len(*flagGrepV) > 0 {
+		filter, err := kail.NewLineFilter(*flagGrep, *flagGrepV, *flagIgnoreCase)
+		kingpin.FatalIfError(err, "invalid --grep or --grep-v expression")
+		opts = append(opts, kail.WithLineFilter(filter))
+
+		before, after := *flagBeforeContext, *flagAfterContext
+		if before == 0 {
+			before = *flagGrepContext
+		}
+		if after == 0 {
+			after = *flagGrepContext
+		}
+		opts = append(opts, kail.WithLineContext(before, after))
+	}
+
+	if *flagMinLevel != "" {
+		level, err := kail.ParseLevel(*flagMinLevel)
+		kingpin.FatalIfError(err, "invalid --min-level")
+		opts = append(opts, kail.WithMinLevel(level, *flagDropUnleveled))
+	}
+
+	for _, expr := range *flagWhere {
+		filter, err := kail.ParseEventFilter(expr)
+		kingpin.FatalIfError(err, "invalid --where expression '%v'", expr)
+		opts = append(opts, kail.WithEventFilter(filter))
+	}
+
+	return opts
+}
+
+// streamLogs displays the events of controller until it is done,
+// or until a line matches --until or --exit-on-pattern.
+func streamLogs(controller kail.Controller) matchResult {
+	until, exitOn := createMatchers()
+
+	var timeoutch <-chan time.Time
+	if *flagTimeout > 0 {
+		t := time.NewTimer(*flagTimeout)
+		defer t.Stop()
+		timeoutch = t.C
+	}
+
 	var writer writers.Writer
+	opts := []writers.Option{
+		writers.WithColor(writers.ColorMode(*flagColor)),
+		writers.WithColorBy(writers.ColorBy(*flagColorBy)),
+	}
 
-	switch *flagOutput {
-	case "default":
-		writer = writers.NewWriter(os.Stdout)
-	case "raw":
+	if *flagTimestamps {
+		opts = append(opts, writers.WithTimestamps())
+	}
+
+	if *flagPrefixTemplate != "" {
+		tmpl, err := writers.ParseTemplate(*flagPrefixTemplate)
+		kingpin.FatalIfError(err, "invalid --prefix-template")
+		opts = append(opts, writers.WithPrefixTemplate(tmpl))
+	}
+
+	switch {
+	case *flagTemplate != "":
+		tmpl, err := writers.ParseTemplate(*flagTemplate)
+		kingpin.FatalIfError(err, "invalid --template")
+		writer = writers.NewTemplateWriter(os.Stdout, tmpl)
+	case *flagOutput == "default":
+		writer = writers.NewWriter(os.Stdout, opts...)
+	case *flagOutput == "raw":
 		writer = writers.NewRawWriter(os.Stdout)
-	case "json":
-		writer = writers.NewJSONWriter(os.Stdout)
-	case "json-pretty":
-		writer = writers.NewJSONPrettyWriter(os.Stdout)
-	case "zerolog":
+	case *flagOutput == "json":
+		writer = writers.NewJSONWriter(os.Stdout, createJSONOptions(opts)...)
+	case *flagOutput == "json-pretty":
+		writer = writers.NewJSONPrettyWriter(os.Stdout, createJSONOptions(opts)...)
+	case *flagOutput == "pretty":
+		opts = append(opts,
+			writers.WithLevelKeys(*flagLevelKey...),
+			writers.WithTimeKeys(*flagTimeKey...),
+			writers.WithMessageKeys(*flagMessageKey...),
+			writers.WithErrorKeys(*flagErrorKey...))
+		writer = writers.NewPrettyWriter(os.Stdout, opts...)
+	case *flagOutput == "logfmt":
+		writer = writers.NewLogfmtWriter(os.Stdout)
+	case *flagOutput == "zerolog":
 		zerolog.TimestampFieldName = *flagZerologTimestampFieldName
 		zerolog.LevelFieldName = *flagZerologLevelFieldName
 		zerolog.MessageFieldName = *flagZerologMessageFieldName
 		zerolog.ErrorFieldName = *flagZerologErrorFieldName
-		writer = writers.NewZerologWriter(os.Stdout)
+		writer = writers.NewZerologWriter(os.Stdout, opts...)
 	default:
 		kingpin.Fatalf("Invalid output: '%v'", *flagOutput)
 	}
 
+	result := matchNone
+	printFailed := false
+
 	for {
 		select {
 		case ev := <-controller.Events():
-			writer.Print(ev)
+			if result != matchNone {
+				// closing; discard events still in flight.
+				continue
+			}
+
+			if err := writer.Print(ev); err != nil && !printFailed {
+				// report once; a template error likely fails every line.
+				printFailed = true
+				fmt.Fprintf(os.Stderr, "kail: error writing output: %v\n", err)
+			}
+
+			switch {
+			case ev.Meta() != kail.MetaNone:
+			case exitOn != nil && exitOn.Accept(ev.Log()):
+				result = matchExit
+				go controller.Close()
+			case until != nil && until.Accept(ev.Log()):
+				result = matchUntil
+				go controller.Close()
+			}
+
+		case <-timeoutch:
+			timeoutch = nil
+			if result == matchNone {
+				result = matchTimeout
+				go controller.Close()
+			}
+
 		case <-controller.Done():
-			return
+			return result
 		}
 	}
 }
 
+// createMatchers returns the line filters for --until and
+// --exit-on-pattern, or nil for those not given.
+func createMatchers() (until, exitOn kail.LineFilter) {
+	if *flagTimeout > 0 && *flagUntil == "" {
+		kingpin.Fatalf("--timeout requires --until")
+	}
+
+	if *flagUntil != "" {
+		filter, err := kail.NewLineFilter([]string{*flagUntil}, nil, *flagIgnoreCase)
+		kingpin.FatalIfError(err, "invalid --until")
+		until = filter
+	}
+
+	if len(*flagExitOnPattern) > 0 {
+		filter, err := kail.NewLineFilter(*flagExitOnPattern, nil, *flagIgnoreCase)
+		kingpin.FatalIfError(err, "invalid --exit-on-pattern")
+		exitOn = filter
+	}
+
+	return until, exitOn
+}
+
+func createJSONOptions(opts []writers.Option) []writers.Option {
+	if *flagFields != "" {
+		fields, err := writers.ParseFields(*flagFields)
+		kingpin.FatalIfError(err, "invalid --fields")
+		opts = append(opts, writers.WithFields(fields...))
+	}
+
+	if *flagFlatten {
+		opts = append(opts, writers.WithFlatten())
+	}
+
+	if *flagIncludeNode {
+		opts = append(opts, writers.WithNode())
+	}
+
+	if *flagIncludeLabels {
+		opts = append(opts, writers.WithLabels())
+	}
+
+	return opts
+}
+
 func parseLabels(name string, vals []string) []labels.Selector {
 	var selectors []labels.Selector
 	for _, val := range vals {
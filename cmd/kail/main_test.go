@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boz/kail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	failure := kail.SourceError{Err: errors.New("boom")}
+
+	tests := []struct {
+		name        string
+		summary     kail.Summary
+		interrupted bool
+		result      matchResult
+		until       bool
+		expected    int
+	}{
+		{"done", kail.Summary{Sources: 2}, false, matchNone, false, 0},
+		{"no sources", kail.Summary{}, false, matchNone, false, exitNoSources},
+		{"interrupted", kail.Summary{Sources: 2}, true, matchNone, false, exitInterrupted},
+		{"all failed", kail.Summary{Sources: 1, Failures: []kail.SourceError{failure}}, false, matchNone, false, exitAllFailed},
+		{"partial failure", kail.Summary{Sources: 2, Failures: []kail.SourceError{failure}}, false, matchNone, false, exitPartialFailure},
+		{"failure over interrupt", kail.Summary{Sources: 2, Failures: []kail.SourceError{failure}}, true, matchNone, false, exitPartialFailure},
+		{"until matched over failure", kail.Summary{Sources: 1, Failures: []kail.SourceError{failure}}, true, matchUntil, true, 0},
+		{"pattern matched over failure", kail.Summary{Sources: 1, Failures: []kail.SourceError{failure}}, true, matchExit, false, exitPatternMatched},
+		{"until timed out", kail.Summary{Sources: 2}, false, matchTimeout, true, exitNotMatched},
+		{"until not matched", kail.Summary{Sources: 2}, false, matchNone, true, exitNotMatched},
+		{"until not matched when interrupted", kail.Summary{Sources: 2}, true, matchNone, true, exitInterrupted},
+		{"until not matched without sources", kail.Summary{}, false, matchNone, true, exitNotMatched},
+	}
+
+	for _, test := range tests {
+		status, _ := exitCode(test.summary, test.interrupted, test.result, test.until, time.Minute)
+		assert.Equal(t, test.expected, status, test.name)
+	}
+}
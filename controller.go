@@ -2,16 +2,22 @@ package kail
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 
 	lifecycle "github.com/boz/go-lifecycle"
 	logutil "github.com/boz/go-logutil"
 	"github.com/boz/kcache"
 	"github.com/boz/kcache/nsname"
+	kevent "github.com/boz/kcache/types/event"
 	"github.com/boz/kcache/types/pod"
 )
 
@@ -23,15 +29,235 @@ type Controller interface {
 	Events() <-chan Event
 	Close()
 	Done() <-chan struct{}
+
+	// Summary reports on the containers streamed.  It
+	// is complete once the controller is done.
+	Summary() Summary
+}
+
+// Summary reports on the containers streamed by a controller.
+type Summary struct {
+	// Sources is the number of containers whose logs were streamed.
+	Sources int
+
+	// Failures are the containers whose logs could not be read
+	// when last streamed.
+	Failures []SourceError
+}
+
+// SourceError is the error that reading the logs of a container failed with.
+type SourceError struct {
+	Source EventSource
+	Err    error
+}
+
+func (e SourceError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Source, e.Err)
+}
+
+type ControllerOption func(*controller)
+
+// DeliveryMode controls how monitors behave when events are produced
+// faster than they are consumed and the event buffer is full.
+type DeliveryMode int
+
+const (
+	// DeliveryDrop drops events that can not be buffered.
+	DeliveryDrop DeliveryMode = iota
+
+	// DeliveryNotify drops events like DeliveryDrop, followed by an
+	// event from the same source reporting the number of lines dropped.
+	DeliveryNotify
+
+	// DeliveryBlock pauses reading logs until the events are buffered.
+	DeliveryBlock
+)
+
+// WithDelivery sets the behavior when the event buffer is full.
+// Defaults to DeliveryDrop.
+func WithDelivery(mode DeliveryMode) ControllerOption {
+	return func(c *controller) {
+		c.delivery = mode
+	}
+}
+
+// WithEventBufferSize sets the number of events buffered between
+// the monitors and the consumer.  Defaults to 500.
+func WithEventBufferSize(n int) ControllerOption {
+	return func(c *controller) {
+		c.eventBufsiz = n
+	}
+}
+
+// WithLineFilter drops events whose log line is not accepted by the filter.
+func WithLineFilter(filter LineFilter) ControllerOption {
+	return func(c *controller) {
+		c.lineFilter = filter
+	}
+}
+
+// WithEventFilter drops events not accepted by the filter.  It may be
+// given more than once, in which case events must be accepted by all.
+func WithEventFilter(filter EventFilter) ControllerOption {
+	return func(c *controller) {
+		c.eventFilters = append(c.eventFilters, filter)
+	}
+}
+
+// WithLineContext forwards up to before and after lines surrounding
+// each line accepted by the filter given to WithLineFilter.
+func WithLineContext(before, after int) ControllerOption {
+	return func(c *controller) {
+		c.contextBefore = before
+		c.contextAfter = after
+	}
+}
+
+// WithLineJoiner joins the lines of multi-line records, such as stack
+// traces, into single events using the given joiner.  Each record is held
+// until the next one starts or until no lines arrive for timeout.
+func WithLineJoiner(joiner LineJoiner, timeout time.Duration) ControllerOption {
+	return func(c *controller) {
+		c.lineJoiner = joiner
+		c.joinTimeout = timeout
+	}
+}
+
+// WithMaxLineSize sets the size in bytes above which log lines are
+// split into multiple partial events, or truncated if truncate is set.
+// Defaults to 1MiB.  See Event.Partial.
+func WithMaxLineSize(size int, truncate bool) ControllerOption {
+	return func(c *controller) {
+		c.maxLineSize = size
+		c.truncateLines = truncate
+	}
+}
+
+// WithReadBufferSize sets the size in bytes of the buffer that each
+// log stream is read into.  Defaults to 16KiB.
+func WithReadBufferSize(size int) ControllerOption {
+	return func(c *controller) {
+		c.readBufsiz = size
+	}
+}
+
+// WithMetaEvents delivers meta events reporting on the containers
+// being followed, such as containers terminating.  See Event.Meta.
+func WithMetaEvents() ControllerOption {
+	return func(c *controller) {
+		c.meta = true
+		c.statuses = make(map[eventSource]v1.ContainerStatus)
+	}
+}
+
+// WithKubernetesEvents delivers Kubernetes Events involving the pods
+// being followed as meta events.  Warning events have level LevelWarn.
+// See DSBuilder.WithEvents.
+func WithKubernetesEvents(events kevent.Controller) ControllerOption {
+	return func(c *controller) {
+		c.kevents = events
+	}
+}
+
+// WithFollow controls whether logs are streamed as they are written.
+// If follow is false, existing logs of the containers running at startup
+// are read and the controller completes once all have been read.
+func WithFollow(follow bool) ControllerOption {
+	return func(c *controller) {
+		c.mconfig.follow = follow
+	}
+}
+
+// WithTail limits the logs first displayed for each container to its last
+// n lines.  If since is non-zero, only lines logged within it are considered.
+// Reconnects resume from the last line seen and are not limited.
+func WithTail(n int64) ControllerOption {
+	return func(c *controller) {
+		c.mconfig.tail = n
+	}
+}
+
+// WithPrevious displays the logs of the previous instance of each
+// restarted container instead of the current one.  The controller
+// completes once all have been read.
+func WithPrevious() ControllerOption {
+	return func(c *controller) {
+		c.mconfig.previous = true
+		c.mconfig.follow = false
+	}
+}
+
+// WithPreviousOnRestart displays the last n lines of the previous instance
+// of a container whenever the container is seen to restart.  All lines of
+// the previous instance are displayed if n is negative.  Lines already
+// displayed while following the container are not displayed again.
+func WithPreviousOnRestart(n int64) ControllerOption {
+	return func(c *controller) {
+		c.restarts = make(map[eventSource]int32)
+		c.restartTail = n
+	}
+}
+
+// WithReorder holds events for the given window and emits them
+// ordered by the time they were logged, across all containers.
+func WithReorder(window time.Duration) ControllerOption {
+	return func(c *controller) {
+		c.reorderWindow = window
+	}
+}
+
+// WithMinLevel drops structured log lines below the given level.
+// Lines without a level are dropped only if dropUnleveled is set.
+func WithMinLevel(min Level, dropUnleveled bool) ControllerOption {
+	return func(c *controller) {
+		c.minLevel = min
+		c.dropUnleveled = dropUnleveled
+	}
+}
+
+// WithMaxStreams limits the number of log streams open at once to n.
+// Further containers wait for a stream to close, in the order given by
+// WithPreferredPods and WithNewestFirst.  Unlimited if n is zero.
+func WithMaxStreams(n int) ControllerOption {
+	return func(c *controller) {
+		c.maxStreams = n
+	}
+}
+
+// WithPreferredPods streams the containers of pods matching any of the
+// selectors before others when waiting for streams.  See WithMaxStreams.
+func WithPreferredPods(selectors ...labels.Selector) ControllerOption {
+	return func(c *controller) {
+		c.queue.prefer = append(c.queue.prefer, selectors...)
+	}
+}
+
+// WithNewestFirst streams the containers of the most recently created
+// pods first when waiting for streams.  See WithMaxStreams.
+func WithNewestFirst() ControllerOption {
+	return func(c *controller) {
+		c.queue.newestFirst = true
+	}
 }
 
+// WithFailFast shuts the controller down once reading the logs
+// of any container fails, rather than retrying it.
+func WithFailFast() ControllerOption {
+	return func(c *controller) {
+		c.failFast = true
+	}
+}
+
+// NewController streams the logs of the containers of pods published by
+// pcontroller.  All log streams share cs and its transport, so its QPS and
+// burst limits apply to opening streams.
 func NewController(
 	ctx context.Context,
 	cs kubernetes.Interface,
-	rc *rest.Config,
 	pcontroller pod.Controller,
 	filter ContainerFilter,
-	since time.Duration) (Controller, error) {
+	since time.Duration,
+	opts ...ControllerOption) (Controller, error) {
 
 	pods, err := pcontroller.Subscribe()
 	if err != nil {
@@ -52,18 +278,42 @@ func NewController(
 
 	c := &controller{
 		cs:        cs,
-		rc:        rc,
 		pods:      pods,
 		filter:    filter,
-		mconfig:   monitorConfig{since: since},
-		eventch:   make(chan Event, eventBufsiz),
+		mconfig:   monitorConfig{since: since, follow: true, tail: -1},
+		outch:     make(chan Event),
 		monitorch: make(chan eventSource),
+		retrych:   make(chan eventSource),
+		failures:  make(map[eventSource]*backoff),
+		cursors:   make(map[eventSource]*cursor),
+		sources:   make(map[eventSource]bool),
+		errors:    make(map[eventSource]error),
 		monitors:  make(map[nsname.NSName]podMonitors),
 		log:       log,
 		ctx:       ctx,
 		lc:        lc,
+
+		readBufsiz:  logBufsiz,
+		maxLineSize: logMaxLineSize,
+		eventBufsiz: eventBufsiz,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.eventch = make(chan Event, c.eventBufsiz)
+
+	if c.kevents != nil {
+		if c.keventSub, err = c.kevents.Subscribe(); err != nil {
+			pods.Close()
+			return nil, err
+		}
+		c.keventsSince = time.Now().Add(-since)
 	}
 
+	c.pipeline = c.createPipeline()
+
 	go c.run(initial)
 
 	return c, nil
@@ -71,16 +321,64 @@ func NewController(
 
 type controller struct {
 	cs     kubernetes.Interface
-	rc     *rest.Config
 	pods   pod.Subscription
 	filter ContainerFilter
 
 	eventch   chan Event
+	outch     chan Event
 	monitorch chan eventSource
+	retrych   chan eventSource
+
+	lineJoiner    LineJoiner
+	joinTimeout   time.Duration
+	minLevel      Level
+	dropUnleveled bool
+	eventFilters  []EventFilter
+	lineFilter    LineFilter
+	contextBefore int
+	contextAfter  int
+	reorderWindow time.Duration
+	pipeline      pipeline
 
 	monitors monitors
 	mconfig  monitorConfig
 
+	// number of monitors, and the sources waiting
+	// for one to complete if limited to maxStreams.
+	streams    int
+	maxStreams int
+	queue      streamQueue
+
+	// delays before recreating the monitors of sources that failed,
+	// and the cursors that the recreated monitors resume from.
+	failures map[eventSource]*backoff
+	cursors  map[eventSource]*cursor
+	failFast bool
+
+	// sources streamed, and the error each failed with when last streamed.
+	sources map[eventSource]bool
+	errors  map[eventSource]error
+
+	readBufsiz    int
+	maxLineSize   int
+	truncateLines bool
+	eventBufsiz   int
+	delivery      DeliveryMode
+
+	// last seen status of each container, if delivering meta events.
+	meta     bool
+	statuses map[eventSource]v1.ContainerStatus
+
+	// kubernetes events, and the time before which they are ignored.
+	kevents      kevent.Controller
+	keventSub    kevent.Subscription
+	keventsSince time.Time
+
+	// last seen restart count of each container, if displaying
+	// previous instances on restart.
+	restarts    map[eventSource]int32
+	restartTail int64
+
 	log logutil.Log
 	ctx context.Context
 	lc  lifecycle.Lifecycle
@@ -90,7 +388,7 @@ type podMonitors map[eventSource]monitor
 type monitors map[nsname.NSName]podMonitors
 
 func (c *controller) Events() <-chan Event {
-	return c.eventch
+	return c.outch
 }
 
 func (c *controller) Done() <-chan struct{} {
@@ -101,14 +399,39 @@ func (c *controller) Close() {
 	c.lc.Shutdown(nil)
 }
 
+func (c *controller) Summary() Summary {
+	select {
+	case <-c.lc.Done():
+	default:
+		return Summary{}
+	}
+
+	summary := Summary{Sources: len(c.sources)}
+	for source, err := range c.errors {
+		summary.Failures = append(summary.Failures, SourceError{source, err})
+	}
+	sort.Slice(summary.Failures, func(i, j int) bool {
+		return summary.Failures[i].Error() < summary.Failures[j].Error()
+	})
+	return summary
+}
+
 func (c *controller) run(initial []*v1.Pod) {
 	defer c.log.Un(c.log.Trace("run"))
 	defer c.lc.ShutdownCompleted()
 
 	peventch := c.pods.Events()
+
+	var keventch <-chan kevent.Event
+	if c.keventSub != nil {
+		keventch = c.keventSub.Events()
+	}
 	shutdownch := c.lc.ShutdownRequest()
 	draining := false
 
+	pipedonech := make(chan struct{})
+	go c.runPipeline(pipedonech)
+
 	c.createInitialMonitors(initial)
 
 	for {
@@ -119,6 +442,11 @@ func (c *controller) run(initial []*v1.Pod) {
 			break
 		}
 
+		if !c.mconfig.follow && len(c.monitors) == 0 {
+			c.log.Debugf("all monitors complete")
+			break
+		}
+
 		select {
 
 		case err := <-shutdownch:
@@ -143,28 +471,154 @@ func (c *controller) run(initial []*v1.Pod) {
 				break
 			}
 
-			if !draining {
+			if !draining && c.mconfig.follow {
 				c.handlePodEvent(ev)
 			}
 
+		case ev, ok := <-keventch:
+			if !ok {
+				c.log.Debugf("events closed")
+				keventch = nil
+				break
+			}
+
+			if !draining {
+				c.handleKubernetesEvent(ev)
+			}
+
 		case source := <-c.monitorch:
 			if pms, ok := c.monitors[source.id]; ok {
-				if _, ok := pms[source]; ok {
+				if pm, ok := pms[source]; ok {
+					switch err := pm.Err(); {
+					case draining || errors.Is(err, context.Canceled):
+						// stopped by shutting down; not a failure.  if it
+						// replaced a monitor that failed, it has recovered.
+						if pm.Started() {
+							c.recordResult(source, nil)
+						}
+					case err == nil:
+						c.recordResult(source, nil)
+					case c.failFast:
+						c.recordResult(source, err)
+						c.log.Debugf("%v failed; shutting down: %v", source, err)
+						c.lc.ShutdownInitiated(err)
+						shutdownch = nil
+						draining = true
+					default:
+						c.recordResult(source, err)
+						c.scheduleRetry(source, pm, err)
+					}
+
 					c.log.Debugf("removing source %v", source)
 					delete(pms, source)
 					if len(pms) == 0 {
 						c.log.Debugf("removing pod %v", source.id)
 						delete(c.monitors, source.id)
 					}
+					c.streams--
+					if draining {
+						c.queue.clear()
+					} else {
+						c.startQueued()
+					}
 					break
 				}
 			}
 			c.log.Warnf("attempted to remove unknown source: %v", source)
+
+		case source := <-c.retrych:
+			if !draining {
+				c.retrySource(source)
+			}
 		}
 	}
 
 	c.pods.Close()
 	<-c.pods.Done()
+
+	if c.keventSub != nil {
+		c.keventSub.Close()
+		<-c.keventSub.Done()
+	}
+
+	// all monitors have stopped; nothing else will write to eventch.
+	close(c.eventch)
+
+	select {
+	case <-pipedonech:
+	case err := <-shutdownch:
+		c.lc.ShutdownInitiated(err)
+		shutdownch = nil
+		<-pipedonech
+	}
+
+	if shutdownch != nil {
+		c.lc.ShutdownInitiated(nil)
+	}
+}
+
+func (c *controller) createPipeline() pipeline {
+	var p pipeline
+
+	if c.lineJoiner != nil {
+		p = append(p, newJoinStage(c.lineJoiner, c.joinTimeout))
+	}
+
+	if c.minLevel != LevelUnknown || c.dropUnleveled {
+		p = append(p, levelFilterStage{c.minLevel, c.dropUnleveled})
+	}
+
+	for _, filter := range c.eventFilters {
+		p = append(p, eventFilterStage{filter})
+	}
+
+	switch {
+	case c.lineFilter == nil:
+	case c.contextBefore > 0 || c.contextAfter > 0:
+		p = append(p, newContextStage(c.lineFilter, c.contextBefore, c.contextAfter))
+	default:
+		p = append(p, lineFilterStage{c.lineFilter})
+	}
+
+	if c.reorderWindow > 0 {
+		p = append(p, newReorderStage(c.reorderWindow))
+	}
+
+	return p
+}
+
+func (c *controller) runPipeline(donech chan struct{}) {
+	defer c.log.Un(c.log.Trace("runPipeline"))
+	defer close(donech)
+
+	var tickch <-chan time.Time
+	if c.pipeline.timed() {
+		ticker := time.NewTicker(pipelineTickInterval)
+		defer ticker.Stop()
+		tickch = ticker.C
+	}
+
+	for {
+		select {
+		case ev, ok := <-c.eventch:
+			if !ok {
+				c.emit(c.pipeline.flush())
+				return
+			}
+			c.emit(c.pipeline.process(ev))
+		case now := <-tickch:
+			c.emit(c.pipeline.tick(now))
+		}
+	}
+}
+
+func (c *controller) emit(events []Event) {
+	for _, ev := range events {
+		select {
+		case c.outch <- ev:
+		case <-c.lc.ShuttingDown():
+		}
+	}
 }
 
 func (c *controller) handlePodEvent(ev pod.Event) {
@@ -175,19 +629,188 @@ func (c *controller) handlePodEvent(ev pod.Event) {
 		ev.Type(), ev.Resource().GetName(), ev.Resource().GetNamespace())
 
 	if ev.Type() == kcache.EventTypeDelete {
+		c.deliverMeta(eventSource{id: id, node: pod.Spec.NodeName},
+			MetaPodDeleted, LevelInfo, "pod deleted")
+		for source := range c.statuses {
+			if source.id == id {
+				delete(c.statuses, source)
+			}
+		}
 		if pms, ok := c.monitors[id]; ok {
 			for _, pm := range pms {
 				pm.Shutdown()
 			}
 		}
+		c.queue.remove(func(source eventSource) bool {
+			return source.id == id
+		})
+		for source := range c.failures {
+			if source.id == id {
+				delete(c.failures, source)
+				delete(c.cursors, source)
+			}
+		}
+		for source, err := range c.errors {
+			// streams of deleted pods may fail before the deletion is seen.
+			if source.id == id && apierrors.IsNotFound(err) {
+				delete(c.errors, source)
+			}
+		}
+		for source := range c.restarts {
+			if source.id == id {
+				delete(c.restarts, source)
+			}
+		}
 		return
 	}
 
+	c.handleStatuses(pod)
+	c.handleRestarts(pod)
 	c.ensureMonitorsForPod(pod)
 }
 
+// handleStatuses delivers meta events for containers
+// that have terminated or restarted.
+func (c *controller) handleStatuses(pod *v1.Pod) {
+	if c.statuses == nil {
+		return
+	}
+
+	id := nsname.ForObject(pod)
+
+	for _, cstatus := range podContainerStatuses(pod) {
+		// filter on name only; terminated and waiting containers are of interest.
+		named := cstatus
+		named.State = v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+		if !c.filter.Accept(named) {
+			continue
+		}
+
+		source := eventSource{id, cstatus.Name, pod.Spec.NodeName, false}
+
+		last, ok := c.statuses[source]
+		c.statuses[source] = cstatus
+
+		switch {
+		case !ok:
+		case cstatus.RestartCount > last.RestartCount:
+			if term := cstatus.LastTerminationState.Terminated; term != nil {
+				c.deliverMeta(source, MetaRestarted, terminatedLevel(term),
+					"restarted (restart count %v) after %v", cstatus.RestartCount, describeTermination(term))
+			} else {
+				c.deliverMeta(source, MetaRestarted, LevelWarn,
+					"restarted (restart count %v)", cstatus.RestartCount)
+			}
+		case cstatus.State.Terminated != nil && last.State.Terminated == nil:
+			term := cstatus.State.Terminated
+			c.deliverMeta(source, MetaTerminated, terminatedLevel(term),
+				"terminated with %v", describeTermination(term))
+		}
+	}
+}
+
+// deliverMeta delivers a meta event if they are enabled.
+func (c *controller) deliverMeta(
+	source EventSource, kind MetaKind, level Level, format string, args ...interface{}) {
+	if c.meta {
+		c.deliver(newMetaEvent(source, kind, level, format, args...))
+	}
+}
+
+// deliver passes an event from the controller to the pipeline.
+func (c *controller) deliver(ev Event) {
+	select {
+	case c.eventch <- ev:
+	case <-c.lc.ShuttingDown():
+	}
+}
+
+// handleKubernetesEvent delivers Kubernetes Events involving
+// the selected pods as meta events.
+func (c *controller) handleKubernetesEvent(ev kevent.Event) {
+	if ev.Type() == kcache.EventTypeDelete {
+		return
+	}
+
+	kev := ev.Resource()
+	ref := kev.InvolvedObject
+
+	if ref.Kind != "Pod" || kubernetesEventTime(kev).Before(c.keventsSince) {
+		return
+	}
+
+	pod, err := c.pods.Cache().Get(ref.Namespace, ref.Name)
+	if err != nil || pod == nil {
+		return
+	}
+
+	source := eventSource{
+		id:        nsname.New(ref.Namespace, ref.Name),
+		container: fieldPathContainer(ref.FieldPath),
+		node:      pod.Spec.NodeName,
+	}
+
+	level := LevelInfo
+	if kev.Type == v1.EventTypeWarning {
+		level = LevelWarn
+	}
+
+	if kev.Count > 1 {
+		c.deliver(newMetaEvent(source, MetaKubernetesEvent, level,
+			"%v: %v (x%v)", kev.Reason, kev.Message, kev.Count))
+	} else {
+		c.deliver(newMetaEvent(source, MetaKubernetesEvent, level,
+			"%v: %v", kev.Reason, kev.Message))
+	}
+}
+
+// kubernetesEventTime returns the time the event last occurred.
+func kubernetesEventTime(kev *v1.Event) time.Time {
+	switch {
+	case !kev.LastTimestamp.IsZero():
+		return kev.LastTimestamp.Time
+	case kev.Series != nil && !kev.Series.LastObservedTime.IsZero():
+		return kev.Series.LastObservedTime.Time
+	case !kev.EventTime.IsZero():
+		return kev.EventTime.Time
+	default:
+		return kev.FirstTimestamp.Time
+	}
+}
+
+// fieldPathContainer returns the container referred to by
+// a field path such as "spec.containers{app}", if any.
+func fieldPathContainer(path string) string {
+	start, end := strings.IndexByte(path, '{'), strings.LastIndexByte(path, '}')
+	if start < 0 || end < start {
+		return ""
+	}
+	return path[start+1 : end]
+}
+
+func describeTermination(term *v1.ContainerStateTerminated) string {
+	if term.Reason != "" {
+		return fmt.Sprintf("exit code %v, reason %v", term.ExitCode, term.Reason)
+	}
+	return fmt.Sprintf("exit code %v", term.ExitCode)
+}
+
+func terminatedLevel(term *v1.ContainerStateTerminated) Level {
+	if term.ExitCode != 0 {
+		return LevelWarn
+	}
+	return LevelInfo
+}
+
 func (c *controller) ensureMonitorsForPod(pod *v1.Pod) {
-	id, sources := sourcesForPod(c.filter, pod)
+	var id nsname.NSName
+	var sources map[eventSource]bool
+
+	if c.mconfig.previous {
+		id, sources = previousSourcesForPod(c.filter, pod)
+	} else {
+		id, sources = sourcesForPod(c.filter, pod)
+	}
 
 	c.log.Debugf("pod %v/%v: %v containers ready",
 		pod.GetNamespace(), pod.GetName(), len(sources))
@@ -195,35 +818,199 @@ func (c *controller) ensureMonitorsForPod(pod *v1.Pod) {
 	// delete monitors of not-ready containers
 	if pms, ok := c.monitors[id]; ok {
 		for source, pm := range pms {
-			if !sources[source] {
+			if !sources[source] && !c.isRestartMonitor(source) {
 				pm.Shutdown()
 			}
 		}
 	}
+	c.queue.remove(func(source eventSource) bool {
+		return source.id == id && !sources[source] && !c.isRestartMonitor(source)
+	})
 
-	if len(sources) == 0 {
+	for source, _ := range sources {
+		if _, ok := c.monitors[id][source]; ok {
+			continue
+		}
+		config := c.mconfig
+		if cur, ok := c.cursors[source]; ok {
+			// recreated after failing; resume where it stopped.
+			config.resume = cur
+			delete(c.cursors, source)
+		}
+		c.startMonitor(pod, source, config)
+	}
+
+	c.updateTerminated(pod)
+}
+
+// updateTerminated reports to the monitors following the
+// pod's containers whether each container is terminated.
+func (c *controller) updateTerminated(pod *v1.Pod) {
+	if !c.mconfig.follow {
 		return
 	}
 
-	pms, ok := c.monitors[id]
-	if !ok {
-		pms = make(map[eventSource]monitor)
+	id := nsname.ForObject(pod)
+	for _, cstatus := range podContainerStatuses(pod) {
+		source := eventSource{id, cstatus.Name, pod.Spec.NodeName, false}
+		if pm, ok := c.monitors[id][source]; ok {
+			pm.SetTerminated(cstatus.State.Terminated != nil)
+		}
 	}
+}
 
-	for source, _ := range sources {
-		if _, ok := pms[source]; ok {
+// handleRestarts displays the previous instance of each container
+// whose restart count has increased, from the last line that its
+// monitor followed, if any.
+func (c *controller) handleRestarts(pod *v1.Pod) {
+	if c.restarts == nil {
+		return
+	}
+
+	id, previous := previousSourcesForPod(c.filter, pod)
+
+	for _, cstatus := range podContainerStatuses(pod) {
+		source := eventSource{id, cstatus.Name, pod.Spec.NodeName, false}
+
+		count, ok := c.restarts[source]
+		c.restarts[source] = cstatus.RestartCount
+
+		if !ok || cstatus.RestartCount <= count {
+			continue
+		}
+
+		config := monitorConfig{tail: c.restartTail, previous: true}
+		if pm, ok := c.monitors[id][source]; ok {
+			// skip the lines of the previous instance already followed.
+			config.resume = pm.Cursor()
+		}
+
+		source.previous = true
+		if !previous[source] {
+			continue
+		}
+
+		c.log.Debugf("%v restarted (count: %v)", source, cstatus.RestartCount)
+
+		if _, ok := c.monitors[id][source]; ok {
+			// still reading an earlier instance.
 			continue
 		}
-		pms[source] = c.createMonitor(source)
+
+		c.startMonitor(pod, source, config)
 	}
+}
+
+// isRestartMonitor reports whether the source is a previous
+// instance displayed by handleRestarts.  these complete on their own.
+func (c *controller) isRestartMonitor(source eventSource) bool {
+	return source.previous && !c.mconfig.previous
+}
+
+// recordResult records the error, if any, that
+// the monitor of source completed with.
+func (c *controller) recordResult(source eventSource, err error) {
+	if err != nil {
+		c.errors[source] = err
+	} else {
+		delete(c.errors, source)
+	}
+}
 
-	c.monitors[id] = pms
+// scheduleRetry arranges for the monitor pm of source, which failed with
+// err, to be recreated after a delay if its container is still running.
+// The recreated monitor resumes from the last line that pm read.
+func (c *controller) scheduleRetry(source eventSource, pm monitor, err error) {
+	if !c.mconfig.follow || c.isRestartMonitor(source) || isPermanentError(err) {
+		return
+	}
+
+	retry, ok := c.failures[source]
+	if !ok {
+		retry = newBackoff()
+		c.failures[source] = retry
+	}
+	delay := retry.next(time.Now())
+
+	if cur := pm.Cursor(); !cur.time.IsZero() {
+		c.cursors[source] = cur
+	}
+
+	c.log.Debugf("%v failed; retrying in %v: %v", source, delay, err)
+
+	time.AfterFunc(delay, func() {
+		select {
+		case c.retrych <- source:
+		case <-c.lc.ShuttingDown():
+		}
+	})
+}
+
+// retrySource recreates the monitors of the pod of a failed
+// source for its containers that are still running.
+func (c *controller) retrySource(source eventSource) {
+	pod, err := c.pods.Cache().Get(source.id.Namespace, source.id.Name)
+	switch {
+	case err != nil:
+		c.log.ErrWarn(err, "retrying %v", source)
+	case pod == nil:
+		c.log.Debugf("not retrying %v: pod gone", source)
+	default:
+		c.ensureMonitorsForPod(pod)
+	}
+}
+
+// startMonitor creates a monitor for source, or queues
+// it if the maximum number of streams are open.
+func (c *controller) startMonitor(pod *v1.Pod, source eventSource, config monitorConfig) {
+	if c.maxStreams <= 0 || c.streams < c.maxStreams {
+		c.addMonitor(source, pod.GetLabels(), config)
+		return
+	}
+
+	if c.queue.contains(source) {
+		return
+	}
+
+	c.queue.push(pod, source, config)
+
+	c.log.Debugf("%v waiting for a stream (%v queued)", source, c.queue.len())
+	c.deliverMeta(source, MetaWaiting, LevelInfo,
+		"waiting for a stream (%v of %v open, %v waiting)", c.streams, c.maxStreams, c.queue.len())
+}
+
+// startQueued creates monitors for queued sources
+// while fewer than the maximum number of streams are open.
+func (c *controller) startQueued() {
+	for c.maxStreams <= 0 || c.streams < c.maxStreams {
+		item, ok := c.queue.pop()
+		if !ok {
+			return
+		}
+		c.addMonitor(item.source, item.labels, item.config)
+
+		if pod, _ := c.pods.Cache().Get(item.source.id.Namespace, item.source.id.Name); pod != nil {
+			c.updateTerminated(pod)
+		}
+	}
+}
+
+func (c *controller) addMonitor(source eventSource, labels map[string]string, config monitorConfig) {
+	pms, ok := c.monitors[source.id]
+	if !ok {
+		pms = make(podMonitors)
+		c.monitors[source.id] = pms
+	}
+	pms[source] = c.createMonitor(source, labels, config)
+	c.sources[source] = true
+	c.streams++
 }
 
-func (c *controller) createMonitor(source eventSource) monitor {
+func (c *controller) createMonitor(
+	source eventSource, labels map[string]string, config monitorConfig) monitor {
 	defer c.log.Un(c.log.Trace("createMonitor(%v)", source))
 
-	m := newMonitor(c, &source, c.mconfig)
+	m := newMonitor(c, podSource{source, labels}, config)
 
 	go func() {
 
@@ -247,6 +1034,8 @@ func (c *controller) createMonitor(source eventSource) monitor {
 func (c *controller) createInitialMonitors(pods []*v1.Pod) {
 	defer c.log.Un(c.log.Trace("createInitialMonitors(pods=%v)", len(pods)))
 	for _, pod := range pods {
+		c.handleStatuses(pod)
+		c.handleRestarts(pod)
 		c.ensureMonitorsForPod(pod)
 	}
 }
@@ -0,0 +1,361 @@
+package kail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lifecycle "github.com/boz/go-lifecycle"
+	logutil "github.com/boz/go-logutil"
+	"github.com/boz/kcache/nsname"
+	"github.com/boz/kcache/types/pod"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestHandleStatuses(t *testing.T) {
+	c := &controller{
+		filter:  NewContainerFilter(nil),
+		eventch: make(chan Event, 10),
+		lc:      lifecycle.New(),
+	}
+	WithMetaEvents()(c)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+
+	c.handleStatuses(pod)
+	assert.Empty(t, c.eventch)
+
+	status := &pod.Status.ContainerStatuses[0]
+	status.RestartCount = 1
+	status.LastTerminationState = v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+	}
+	c.handleStatuses(pod)
+
+	ev := <-c.eventch
+	assert.Equal(t, MetaRestarted, ev.Meta())
+	assert.Equal(t, LevelWarn, ev.Level())
+	assert.Equal(t, "app", ev.Source().Container())
+	assert.Equal(t, "restarted (restart count 1) after exit code 137, reason OOMKilled", string(ev.Log()))
+
+	status.State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}
+	c.handleStatuses(pod)
+	c.handleStatuses(pod)
+
+	ev = <-c.eventch
+	assert.Equal(t, MetaTerminated, ev.Meta())
+	assert.Equal(t, LevelInfo, ev.Level())
+	assert.Equal(t, "terminated with exit code 0", string(ev.Log()))
+	assert.Empty(t, c.eventch)
+}
+
+func TestHandleRestarts(t *testing.T) {
+	c := &controller{
+		filter:     NewContainerFilter(nil),
+		mconfig:    monitorConfig{follow: true, tail: -1},
+		monitors:   make(monitors),
+		maxStreams: 1,
+		streams:    1,
+		log:        logutil.FromContextOrDefault(context.Background()),
+		lc:         lifecycle.New(),
+	}
+	WithPreviousOnRestart(5)(c)
+
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}, {
+				Name:  "sidecar",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+	id := nsname.ForObject(p)
+	app := eventSource{id: id, container: "app"}
+	sidecar := eventSource{id: id, container: "sidecar"}
+
+	// app is followed; sidecar is not.
+	followed := newCursor()
+	followed.filter([]Event{newEvent(app, []byte("a"), time.Now())})
+	c.monitors[id] = podMonitors{app: &testMonitor{cur: followed}}
+
+	c.handleRestarts(p)
+	assert.Zero(t, c.queue.len())
+
+	for i := range p.Status.ContainerStatuses {
+		status := &p.Status.ContainerStatuses[i]
+		status.RestartCount = 1
+		status.LastTerminationState = v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{ExitCode: 1},
+		}
+	}
+
+	// the streams are all open, so the previous instances are queued.
+	c.handleRestarts(p)
+	require.Equal(t, 2, c.queue.len())
+
+	for c.queue.len() > 0 {
+		item, _ := c.queue.pop()
+		assert.True(t, item.source.previous)
+		assert.True(t, c.isRestartMonitor(item.source))
+		assert.True(t, item.config.previous)
+		assert.False(t, item.config.follow)
+		assert.Equal(t, int64(5), item.config.tail)
+
+		switch item.source.container {
+		case "app":
+			if assert.NotNil(t, item.config.resume) {
+				assert.Equal(t, followed.time, item.config.resume.time)
+			}
+		case "sidecar":
+			assert.Nil(t, item.config.resume)
+		}
+	}
+
+	// no further restarts.
+	c.handleRestarts(p)
+	assert.Zero(t, c.queue.len())
+
+	assert.False(t, c.isRestartMonitor(app))
+	assert.False(t, c.isRestartMonitor(sidecar))
+	WithPrevious()(c)
+	previous := app
+	previous.previous = true
+	assert.False(t, c.isRestartMonitor(previous))
+}
+
+func TestFieldPathContainer(t *testing.T) {
+	assert.Equal(t, "app", fieldPathContainer("spec.containers{app}"))
+	assert.Equal(t, "init", fieldPathContainer("spec.initContainers{init}"))
+	assert.Equal(t, "", fieldPathContainer(""))
+}
+
+func TestSummary(t *testing.T) {
+	c := &controller{
+		lc:      lifecycle.New(),
+		sources: make(map[eventSource]bool),
+		errors:  make(map[eventSource]error),
+	}
+
+	app := eventSource{id: nsname.New("ns", "pod"), container: "app"}
+	sidecar := eventSource{id: nsname.New("ns", "pod"), container: "sidecar"}
+	c.sources[app] = true
+	c.sources[sidecar] = true
+
+	c.recordResult(app, errors.New("boom"))
+	c.recordResult(sidecar, errors.New("boom"))
+	c.recordResult(sidecar, nil)
+
+	assert.Equal(t, Summary{}, c.Summary())
+
+	go c.lc.ShutdownCompleted()
+	<-c.lc.Done()
+
+	summary := c.Summary()
+	assert.Equal(t, 2, summary.Sources)
+	assert.Len(t, summary.Failures, 1)
+	assert.Equal(t, "ns/pod@app: boom", summary.Failures[0].Error())
+}
+
+func TestControllerCancel(t *testing.T) {
+	// stream a line and then hold the stream open until the client goes away.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v hello\n", time.Now().UTC().Format(time.RFC3339Nano))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	p := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	for i := 0; i < 10; i++ {
+		p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:  fmt.Sprintf("c%v", i),
+			State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := NewController(ctx, cs, newTestPodController(p), NewContainerFilter(nil), 0)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		<-c.Events()
+	}
+	cancel()
+	<-c.Done()
+
+	summary := c.Summary()
+	assert.Equal(t, 10, summary.Sources)
+	assert.Empty(t, summary.Failures)
+}
+
+func TestControllerCloseAfterRetry(t *testing.T) {
+	// fail the first request, then stream a line and hold the
+	// stream open until the client goes away.
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%v hello\n", time.Now().UTC().Format(time.RFC3339Nano))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+
+	c, err := NewController(context.Background(), cs, newTestPodController(p), NewContainerFilter(nil), 0)
+	require.NoError(t, err)
+
+	select {
+	case <-c.Events():
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed monitor was not recreated")
+	}
+	c.Close()
+	<-c.Done()
+
+	summary := c.Summary()
+	assert.Equal(t, 1, summary.Sources)
+	assert.Empty(t, summary.Failures)
+}
+
+func TestRetryResumesFromCursor(t *testing.T) {
+	c := &controller{
+		filter:     NewContainerFilter(nil),
+		mconfig:    monitorConfig{follow: true, tail: 10},
+		monitors:   make(monitors),
+		failures:   make(map[eventSource]*backoff),
+		cursors:    make(map[eventSource]*cursor),
+		maxStreams: 1,
+		streams:    1,
+		log:        logutil.FromContextOrDefault(context.Background()),
+		lc:         lifecycle.New(),
+	}
+
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+	source := eventSource{id: nsname.ForObject(p), container: "app"}
+
+	failed := newCursor()
+	failed.filter([]Event{newEvent(source, []byte("a"), time.Now())})
+
+	c.scheduleRetry(source, &testMonitor{cur: failed}, errors.New("boom"))
+	require.Contains(t, c.cursors, source)
+
+	// the streams are all open, so the recreated monitor is queued.
+	c.ensureMonitorsForPod(p)
+	item, ok := c.queue.pop()
+	require.True(t, ok)
+	assert.Equal(t, source, item.source)
+	assert.Equal(t, failed.time, item.config.resume.time)
+	assert.Equal(t, int64(10), item.config.tail)
+	assert.Empty(t, c.cursors)
+}
+
+// testPodController publishes a fixed set of pods.
+type testPodController struct {
+	pod.Controller
+	pods []*v1.Pod
+}
+
+func newTestPodController(pods ...*v1.Pod) *testPodController {
+	return &testPodController{pods: pods}
+}
+
+func (c *testPodController) Subscribe() (pod.Subscription, error) {
+	return &testPodSubscription{
+		pods:    c.pods,
+		eventch: make(chan pod.Event),
+		donech:  make(chan struct{}),
+	}, nil
+}
+
+type testPodSubscription struct {
+	pods    []*v1.Pod
+	eventch chan pod.Event
+	donech  chan struct{}
+	once    sync.Once
+}
+
+func (s *testPodSubscription) Cache() pod.CacheReader { return s }
+
+func (s *testPodSubscription) Ready() <-chan struct{} {
+	readych := make(chan struct{})
+	close(readych)
+	return readych
+}
+
+func (s *testPodSubscription) Get(ns, name string) (*v1.Pod, error) {
+	for _, p := range s.pods {
+		if p.Namespace == ns && p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *testPodSubscription) List() ([]*v1.Pod, error) { return s.pods, nil }
+
+func (s *testPodSubscription) Events() <-chan pod.Event { return s.eventch }
+
+func (s *testPodSubscription) Close() { s.once.Do(func() { close(s.donech) }) }
+
+func (s *testPodSubscription) Done() <-chan struct{} { return s.donech }
+
+// testMonitor stands in for a monitor that has read up to cur.
+type testMonitor struct {
+	cur *cursor
+}
+
+func (m *testMonitor) Shutdown()                     {}
+func (m *testMonitor) Done() <-chan struct{}         { return nil }
+func (m *testMonitor) Err() error                    { return nil }
+func (m *testMonitor) Started() bool                 { return true }
+func (m *testMonitor) Cursor() *cursor               { return m.cur.copy() }
+func (m *testMonitor) SetTerminated(terminated bool) {}
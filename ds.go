@@ -6,6 +6,7 @@ import (
 	logutil "github.com/boz/go-logutil"
 	"github.com/boz/kcache/types/daemonset"
 	"github.com/boz/kcache/types/deployment"
+	kevent "github.com/boz/kcache/types/event"
 	"github.com/boz/kcache/types/ingress"
 	"github.com/boz/kcache/types/job"
 	"github.com/boz/kcache/types/node"
@@ -18,6 +19,11 @@ import (
 
 type DS interface {
 	Pods() pod.Controller
+
+	// Events returns Kubernetes Events if requested
+	// with DSBuilder.WithEvents, or nil.
+	Events() kevent.Controller
+
 	Ready() <-chan struct{}
 	Done() <-chan struct{}
 	Close()
@@ -34,6 +40,7 @@ type datastore struct {
 	statefulsetBase statefulset.Controller
 	jobsBase        job.Controller
 	ingressesBase   ingress.Controller
+	eventsBase      kevent.Controller
 
 	pods         pod.Controller
 	services     service.Controller
@@ -61,6 +68,10 @@ func (ds *datastore) Pods() pod.Controller {
 	return ds.pods
 }
 
+func (ds *datastore) Events() kevent.Controller {
+	return ds.eventsBase
+}
+
 func (ds *datastore) Ready() <-chan struct{} {
 	return ds.readych
 }
@@ -122,6 +133,7 @@ func (ds *datastore) controllers() []cacheController {
 		ds.deploymentsBase,
 		ds.statefulsetBase,
 		ds.ingressesBase,
+		ds.eventsBase,
 		ds.pods,
 		ds.services,
 		ds.nodes,
@@ -9,6 +9,7 @@ import (
 	"github.com/boz/kcache/nsname"
 	"github.com/boz/kcache/types/daemonset"
 	"github.com/boz/kcache/types/deployment"
+	kevent "github.com/boz/kcache/types/event"
 	"github.com/boz/kcache/types/ingress"
 	"github.com/boz/kcache/types/job"
 	"github.com/boz/kcache/types/pod"
@@ -38,6 +39,10 @@ type DSBuilder interface {
 	WithIngress(id ...nsname.NSName) DSBuilder
 	WithRegex(s string) DSBuilder
 
+	// WithEvents watches Kubernetes Events in the namespaces that pods
+	// are watched in.  See DS.Events.
+	WithEvents() DSBuilder
+
 	Create(ctx context.Context, cs kubernetes.Interface) (DS, error)
 }
 
@@ -61,6 +66,7 @@ type dsBuilder struct {
 	jobs         []nsname.NSName
 	ingresses    []nsname.NSName
 	regex        string
+	events       bool
 }
 
 func (b *dsBuilder) WithIgnore(selector ...labels.Selector) DSBuilder {
@@ -138,6 +144,11 @@ func (b *dsBuilder) WithRegex(s string) DSBuilder {
 	return b
 }
 
+func (b *dsBuilder) WithEvents() DSBuilder {
+	b.events = true
+	return b
+}
+
 func (b *dsBuilder) Create(ctx context.Context, cs kubernetes.Interface) (DS, error) {
 	log := logutil.FromContextOrDefault(ctx)
 
@@ -425,6 +436,14 @@ func (b *dsBuilder) Create(ctx context.Context, cs kubernetes.Interface) (DS, er
 		}
 	}
 
+	if b.events {
+		ds.eventsBase, err = kevent.NewController(ctx, log, cs, namespace)
+		if err != nil {
+			ds.closeAll()
+			return nil, log.Err(err, "event base controller")
+		}
+	}
+
 	ds.run(ctx)
 
 	return ds, nil
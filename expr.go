@@ -0,0 +1,568 @@
+package kail
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseEventFilter parses an expression over the fields of structured
+// log lines into an EventFilter, as in
+//
+//	status>=500 && path=~"^/api"
+//
+// Operands are fields of the message, string and number literals, true
+// and false, or the event's $namespace, $pod, $container, $node, $level,
+// $previous and $log.  Nested fields are referenced with dots, as in
+// http.status.  Single quoted strings are not unescaped, which suits
+// regular expressions.
+//
+// Comparisons are numeric when either side is a number, by level when
+// either side is $level, and by string otherwise.  Comparisons involving
+// missing fields or unknown levels are false.  A lone operand is true
+// if it is present and not false, zero or empty.  Expressions are
+// combined with &&, || and !, and grouped with parentheses.
+func ParseEventFilter(text string) (EventFilter, error) {
+	tokens, err := lexExpr(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok().kind != tokenEOF {
+		return nil, p.errorf("unexpected '%v'", p.tok().text)
+	}
+
+	return exprFilter{text, node}, nil
+}
+
+type exprFilter struct {
+	text string
+	node exprNode
+}
+
+func (f exprFilter) Accept(ev Event) bool {
+	return f.node.match(ev)
+}
+
+func (f exprFilter) String() string {
+	return f.text
+}
+
+// exprMeta are the event attributes available as $name.
+var exprMeta = map[string]func(ev Event) interface{}{
+	"namespace": func(ev Event) interface{} { return ev.Source().Namespace() },
+	"pod":       func(ev Event) interface{} { return ev.Source().Name() },
+	"container": func(ev Event) interface{} { return ev.Source().Container() },
+	"node":      func(ev Event) interface{} { return ev.Source().Node() },
+	"previous":  func(ev Event) interface{} { return ev.Source().Previous() },
+	"level":     func(ev Event) interface{} { return ev.Level() },
+	"log":       func(ev Event) interface{} { return strings.TrimRight(string(ev.Log()), "\r\n") },
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+
+	// decoded value of string and number tokens.
+	value interface{}
+}
+
+// exprOps are the operators, longest first.
+var exprOps = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!"}
+
+func lexExpr(text string) ([]token, error) {
+	var tokens []token
+
+	for pos := 0; pos < len(text); {
+		c := rune(text[pos])
+		start := pos
+
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+			continue
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			pos++
+			continue
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			pos++
+			continue
+
+		case c == '"':
+			end := pos + 1
+			for ; end < len(text) && text[end] != '"'; end++ {
+				if text[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(text) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			value, err := strconv.Unquote(text[pos : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %v", start+1, err)
+			}
+			pos = end + 1
+			tokens = append(tokens, token{tokenString, text[start:pos], start, value})
+			continue
+
+		case c == '\'':
+			end := strings.IndexByte(text[pos+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			pos += end + 2
+			tokens = append(tokens, token{tokenString, text[start:pos], start, text[start+1 : pos-1]})
+			continue
+
+		case isDigit(c) || (c == '-' && pos+1 < len(text) && isDigit(rune(text[pos+1]))):
+			for pos++; pos < len(text) && isNumberChar(text, pos); pos++ {
+			}
+			value, err := strconv.ParseFloat(text[start:pos], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%v' at position %d", text[start:pos], start+1)
+			}
+			tokens = append(tokens, token{tokenNumber, text[start:pos], start, value})
+			continue
+
+		case isIdentStart(c):
+			for pos++; pos < len(text) && isIdentChar(rune(text[pos])); pos++ {
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: text[start:pos], pos: start})
+			continue
+		}
+
+		op := ""
+		for _, candidate := range exprOps {
+			if strings.HasPrefix(text[pos:], candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("unexpected '%c' at position %d", c, start+1)
+		}
+		pos += len(op)
+		tokens = append(tokens, token{kind: tokenOp, text: op, pos: start})
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(text)}), nil
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNumberChar(text string, pos int) bool {
+	switch c := text[pos]; {
+	case isDigit(rune(c)), c == '.', c == 'e', c == 'E':
+		return true
+	case c == '+' || c == '-':
+		return text[pos-1] == 'e' || text[pos-1] == 'E'
+	default:
+		return false
+	}
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || c == '$' || c == '@' || unicode.IsLetter(c)
+}
+
+func isIdentChar(c rune) bool {
+	return c == '_' || c == '.' || c == '-' || c == '@' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) tok() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) isOp(ops ...string) bool {
+	if tok := p.tok(); tok.kind == tokenOp {
+		for _, op := range ops {
+			if tok.text == op {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if tok := p.tok(); tok.kind != tokenEOF {
+		return fmt.Errorf("%v at position %d", msg, tok.pos+1)
+	}
+	return fmt.Errorf("%v at end of expression", msg)
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = exprOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = exprAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	switch {
+	case p.isOp("!"):
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return exprNot{node}, nil
+
+	case p.tok().kind == tokenLParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok().kind != tokenRParen {
+			return nil, p.errorf("expected ')'")
+		}
+		p.next()
+		return node, nil
+
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.isOp("=~", "!~"):
+		op := p.next().text
+		tok := p.tok()
+		if tok.kind != tokenString {
+			return nil, p.errorf("expected a regular expression string after '%v'", op)
+		}
+		regex, err := regexp.Compile(tok.value.(string))
+		if err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+		p.next()
+		return exprMatch{left, regex, op == "!~"}, nil
+
+	case p.isOp("==", "!=", "<", "<=", ">", ">="):
+		op := p.next().text
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return exprCompare{op, left, right}, nil
+
+	default:
+		return exprTruthy{left}, nil
+	}
+}
+
+func (p *exprParser) parseOperand() (exprOperand, error) {
+	tok := p.tok()
+
+	switch tok.kind {
+	case tokenString, tokenNumber:
+		p.next()
+		return exprLiteral{tok.value}, nil
+
+	case tokenIdent:
+		switch {
+		case tok.text == "true" || tok.text == "false":
+			p.next()
+			return exprLiteral{tok.text == "true"}, nil
+		case strings.HasPrefix(tok.text, "$"):
+			fn, ok := exprMeta[tok.text[1:]]
+			if !ok {
+				return nil, p.errorf("unknown attribute '%v'", tok.text)
+			}
+			p.next()
+			return exprAttribute(fn), nil
+		default:
+			p.next()
+			return exprField(tok.text), nil
+		}
+
+	case tokenEOF:
+		return nil, p.errorf("expected a field or value")
+
+	default:
+		return nil, p.errorf("expected a field or value, found '%v'", tok.text)
+	}
+}
+
+type exprNode interface {
+	match(ev Event) bool
+}
+
+type exprOr struct {
+	left, right exprNode
+}
+
+func (n exprOr) match(ev Event) bool {
+	return n.left.match(ev) || n.right.match(ev)
+}
+
+type exprAnd struct {
+	left, right exprNode
+}
+
+func (n exprAnd) match(ev Event) bool {
+	return n.left.match(ev) && n.right.match(ev)
+}
+
+type exprNot struct {
+	node exprNode
+}
+
+func (n exprNot) match(ev Event) bool {
+	return !n.node.match(ev)
+}
+
+type exprTruthy struct {
+	operand exprOperand
+}
+
+func (n exprTruthy) match(ev Event) bool {
+	switch value := n.operand.value(ev).(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case string:
+		return value != ""
+	case float64:
+		return value != 0
+	case Level:
+		return value != LevelUnknown
+	default:
+		return true
+	}
+}
+
+type exprMatch struct {
+	operand exprOperand
+	regex   *regexp.Regexp
+	negate  bool
+}
+
+func (n exprMatch) match(ev Event) bool {
+	value := n.operand.value(ev)
+	if value == nil {
+		return false
+	}
+	return n.regex.MatchString(exprString(value)) != n.negate
+}
+
+type exprCompare struct {
+	op          string
+	left, right exprOperand
+}
+
+func (n exprCompare) match(ev Event) bool {
+	cmp, ok := compareExprValues(n.left.value(ev), n.right.value(ev))
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// compareExprValues compares a and b, reporting false
+// if they cannot be compared.
+func compareExprValues(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	_, alevel := a.(Level)
+	_, blevel := b.(Level)
+	if alevel || blevel {
+		la, lb := exprLevel(a), exprLevel(b)
+		if la == LevelUnknown || lb == LevelUnknown {
+			return 0, false
+		}
+		return int(la) - int(lb), true
+	}
+
+	_, anum := a.(float64)
+	_, bnum := b.(float64)
+	if anum || bnum {
+		na, aok := exprNumber(a)
+		nb, bok := exprNumber(b)
+		switch {
+		case !aok || !bok:
+			return 0, false
+		case na < nb:
+			return -1, true
+		case na > nb:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	return strings.Compare(exprString(a), exprString(b)), true
+}
+
+func exprLevel(value interface{}) Level {
+	if level, ok := value.(Level); ok {
+		return level
+	}
+	return LevelOf(value)
+}
+
+func exprNumber(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case string:
+		n, err := strconv.ParseFloat(value, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func exprString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		buf, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(buf)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// exprOperand is a value in an expression.  missing
+// values and JSON nulls are nil.
+type exprOperand interface {
+	value(ev Event) interface{}
+}
+
+type exprLiteral struct {
+	v interface{}
+}
+
+func (o exprLiteral) value(Event) interface{} {
+	return o.v
+}
+
+type exprAttribute func(ev Event) interface{}
+
+func (o exprAttribute) value(ev Event) interface{} {
+	return o(ev)
+}
+
+type exprField string
+
+func (o exprField) value(ev Event) interface{} {
+	value, _ := LookupField(ev.Fields(), string(o))
+	return value
+}
+
+// LookupField finds key in fields, descending into nested
+// objects at dots if there is no field by the full name.
+func LookupField(fields map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := fields[key]; ok {
+		return value, true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '.' {
+			continue
+		}
+		if nested, ok := fields[key[:i]].(map[string]interface{}); ok {
+			if value, ok := LookupField(nested, key[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,59 @@
+package kail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boz/kcache/nsname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	source := eventSource{id: nsname.New("ns", "web-1"), container: "app"}
+	json := newEvent(source,
+		[]byte(`{"level":"warn","status":503,"path":"/api/users","http":{"method":"POST"},"ok":false}`+"\n"), time.Time{})
+	logfmt := newEvent(source, []byte(`level=info status=200 path=/healthz`), time.Time{})
+	text := newEvent(source, []byte(`starting server`), time.Time{})
+
+	for expr, expected := range map[string][3]bool{
+		`status>=500`:                        {true, false, false},
+		`status == "200"`:                    {false, true, false},
+		`status>=500 && path=~"^/api"`:       {true, false, false},
+		`path!~'^/api/\w+$'`:                 {false, true, false},
+		`$level>="warn"`:                     {true, false, false},
+		`$level<"warn" || !status`:           {false, true, true},
+		`http.method=="POST"`:                {true, false, false},
+		`ok`:                                 {false, false, false},
+		`ok==false`:                          {true, false, false},
+		`missing!=1`:                         {false, false, false},
+		`$pod=~"^web-" && $container=="app"`: {true, true, true},
+		`$log=~"server$"`:                    {false, false, true},
+		`!(status<300) && status>-1.5e1`:     {true, false, false},
+	} {
+		filter, err := ParseEventFilter(expr)
+		if !assert.NoError(t, err, expr) {
+			continue
+		}
+		assert.Equal(t, expected[0], filter.Accept(json), "json: %v", expr)
+		assert.Equal(t, expected[1], filter.Accept(logfmt), "logfmt: %v", expr)
+		assert.Equal(t, expected[2], filter.Accept(text), "text: %v", expr)
+	}
+
+	for expr, msg := range map[string]string{
+		``:                   "expected a field or value at end of expression",
+		`status>=`:           "expected a field or value at end of expression",
+		`status>=500 &&`:     "expected a field or value at end of expression",
+		`(status>=500`:       "expected ')' at end of expression",
+		`status 500`:         "unexpected '500' at position 8",
+		`path=~api`:          "expected a regular expression string after '=~' at position 7",
+		`path=~"("`:          "invalid regular expression: error parsing regexp: missing closing ): `(` at position 7",
+		`$pods=="a"`:         "unknown attribute '$pods' at position 1",
+		`msg=="unterminated`: "unterminated string at position 6",
+		`status = 500`:       "unexpected '=' at position 8",
+	} {
+		_, err := ParseEventFilter(expr)
+		if assert.Error(t, err, expr) {
+			assert.Equal(t, msg, err.Error(), expr)
+		}
+	}
+}
@@ -35,20 +35,138 @@ func (cf containerFilter) Accept(cs v1.ContainerStatus) bool {
 	return false
 }
 
+type LineFilter interface {
+	Accept(log []byte) bool
+}
+
+// EventFilter decides whether an event is delivered.
+// See ParseEventFilter.
+type EventFilter interface {
+	Accept(ev Event) bool
+}
+
+// NewLineFilter returns a LineFilter that accepts lines which match any of
+// the include patterns and none of the exclude patterns.  If no include
+// patterns are given, all lines not excluded are accepted.
+func NewLineFilter(include, exclude []string, ignoreCase bool) (LineFilter, error) {
+	var err error
+	f := lineFilter{}
+
+	if f.include, err = compileLinePatterns(include, ignoreCase); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compileLinePatterns(exclude, ignoreCase); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type lineFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func (f lineFilter) Accept(log []byte) bool {
+	for _, regex := range f.exclude {
+		if regex.Match(log) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, regex := range f.include {
+		if regex.Match(log) {
+			return true
+		}
+	}
+	return false
+}
+
+// LineJoiner groups the lines of multi-line records, such as stack
+// traces, so that each record is delivered as a single event.
+type LineJoiner interface {
+	// Continues reports whether log continues the record
+	// of the preceding line from the same source.
+	Continues(log []byte) bool
+}
+
+// NewIndentJoiner returns a LineJoiner that joins lines
+// starting with whitespace to the preceding line.
+func NewIndentJoiner() LineJoiner {
+	return indentJoiner{}
+}
+
+type indentJoiner struct{}
+
+func (indentJoiner) Continues(log []byte) bool {
+	return len(log) > 0 && (log[0] == ' ' || log[0] == '\t')
+}
+
+// NewRecordStartJoiner returns a LineJoiner that starts a new record at
+// each line matching pattern, such as a leading timestamp.  Other lines
+// are joined to the preceding line.
+func NewRecordStartJoiner(pattern string) (LineJoiner, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return recordStartJoiner{regex}, nil
+}
+
+type recordStartJoiner struct {
+	regex *regexp.Regexp
+}
+
+func (j recordStartJoiner) Continues(log []byte) bool {
+	return !j.regex.Match(log)
+}
+
+func compileLinePatterns(patterns []string, ignoreCase bool) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		regexes = append(regexes, regex)
+	}
+	return regexes, nil
+}
+
 func sourcesForPod(filter ContainerFilter, pod *v1.Pod) (nsname.NSName, map[eventSource]bool) {
 	id := nsname.ForObject(pod)
 	sources := make(map[eventSource]bool)
 
-	for _, cstatus := range pod.Status.ContainerStatuses {
+	for _, cstatus := range podContainerStatuses(pod) {
 		if filter.Accept(cstatus) {
-			source := eventSource{id, cstatus.Name, pod.Spec.NodeName}
+			source := eventSource{id, cstatus.Name, pod.Spec.NodeName, false}
 			sources[source] = true
 		}
 	}
 
-	for _, cstatus := range pod.Status.InitContainerStatuses {
+	return id, sources
+}
+
+// previousSourcesForPod returns the previous instances of the pod's
+// containers that have been restarted.
+func previousSourcesForPod(filter ContainerFilter, pod *v1.Pod) (nsname.NSName, map[eventSource]bool) {
+	id := nsname.ForObject(pod)
+	sources := make(map[eventSource]bool)
+
+	for _, cstatus := range podContainerStatuses(pod) {
+		if cstatus.LastTerminationState.Terminated == nil {
+			continue
+		}
+
+		// filter on the state of the previous instance.
+		cstatus.State = cstatus.LastTerminationState
+
 		if filter.Accept(cstatus) {
-			source := eventSource{id, cstatus.Name, pod.Spec.NodeName}
+			source := eventSource{id, cstatus.Name, pod.Spec.NodeName, true}
 			sources[source] = true
 		}
 	}
@@ -56,6 +174,13 @@ func sourcesForPod(filter ContainerFilter, pod *v1.Pod) (nsname.NSName, map[even
 	return id, sources
 }
 
+func podContainerStatuses(pod *v1.Pod) []v1.ContainerStatus {
+	statuses := make([]v1.ContainerStatus, 0,
+		len(pod.Status.ContainerStatuses)+len(pod.Status.InitContainerStatuses))
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	return append(statuses, pod.Status.InitContainerStatuses...)
+}
+
 func SourcesForPod(
 	filter ContainerFilter, pod *v1.Pod) (nsname.NSName, []EventSource) {
 
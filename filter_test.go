@@ -0,0 +1,75 @@
+package kail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		include    []string
+		exclude    []string
+		ignoreCase bool
+		accepted   []string
+		rejected   []string
+	}{
+		{
+			name:     "none",
+			accepted: []string{"", "anything"},
+		},
+		{
+			name:     "include",
+			include:  []string{"error"},
+			accepted: []string{"an error occurred", "error"},
+			rejected: []string{"", "all good", "ERROR"},
+		},
+		{
+			name:     "exclude",
+			exclude:  []string{"^debug"},
+			accepted: []string{"", "info: debug later"},
+			rejected: []string{"debug: details"},
+		},
+		{
+			name:     "exclude wins",
+			include:  []string{"error"},
+			exclude:  []string{"healthz"},
+			accepted: []string{"error: db"},
+			rejected: []string{"error: /healthz", "GET /healthz", "all good"},
+		},
+		{
+			name:     "multiple",
+			include:  []string{"error", `status=5\d\d`},
+			exclude:  []string{"retry", "timeout"},
+			accepted: []string{"error: db", "status=503"},
+			rejected: []string{"status=200", "error: retry", "status=504 timeout"},
+		},
+		{
+			name:       "ignore case",
+			include:    []string{"error"},
+			exclude:    []string{"Retry"},
+			ignoreCase: true,
+			accepted:   []string{"ERROR: db", "Error"},
+			rejected:   []string{"ERROR: RETRY", "warning"},
+		},
+	}
+
+	for _, test := range tests {
+		filter, err := NewLineFilter(test.include, test.exclude, test.ignoreCase)
+		require.NoError(t, err, test.name)
+		for _, line := range test.accepted {
+			assert.True(t, filter.Accept([]byte(line)), "%v: %q", test.name, line)
+		}
+		for _, line := range test.rejected {
+			assert.False(t, filter.Accept([]byte(line)), "%v: %q", test.name, line)
+		}
+	}
+
+	_, err := NewLineFilter([]string{"("}, nil, false)
+	assert.Error(t, err)
+
+	_, err = NewLineFilter(nil, []string{"["}, false)
+	assert.Error(t, err)
+}
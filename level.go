@@ -0,0 +1,117 @@
+package kail
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level is the severity of a structured log message, normalized
+// across the conventions of common logging libraries.
+type Level int
+
+const (
+	// LevelUnknown is the level of messages without a recognized level.
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = []string{"", "trace", "debug", "info", "warn", "error", "fatal"}
+
+// fields that common logging libraries record levels under.
+var levelKeys = []string{"level", "lvl", "severity", "log.level", "levelname"}
+
+func (l Level) String() string {
+	if l < LevelUnknown || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name: one of
+// trace, debug, info, warn, error or fatal.
+func ParseLevel(name string) (Level, error) {
+	for i, lname := range levelNames {
+		if lname != "" && strings.EqualFold(name, lname) {
+			return Level(i), nil
+		}
+	}
+	return LevelUnknown, fmt.Errorf("invalid level: '%v'", name)
+}
+
+// MessageLevel returns the level of a structured message, as found
+// under the keys used by common logging libraries.
+func MessageLevel(fields map[string]interface{}) Level {
+	for _, key := range levelKeys {
+		if value, ok := fields[key]; ok {
+			return LevelOf(value)
+		}
+	}
+	return LevelUnknown
+}
+
+// LevelKeys returns the keys that MessageLevel looks for levels under.
+func LevelKeys() []string {
+	return append([]string(nil), levelKeys...)
+}
+
+// LevelOf normalizes the value of a level field.  Level names are matched
+// case insensitively along with common aliases such as "warning" and
+// "critical".  Numeric values are interpreted as bunyan and pino levels.
+func LevelOf(value interface{}) Level {
+	switch value := value.(type) {
+	case float64:
+		return numericLevel(value)
+	case string:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return numericLevel(n)
+		}
+		return namedLevel(strings.ToLower(value))
+	default:
+		return LevelUnknown
+	}
+}
+
+func numericLevel(n float64) Level {
+	switch {
+	case n >= 60:
+		return LevelFatal
+	case n >= 50:
+		return LevelError
+	case n >= 40:
+		return LevelWarn
+	case n >= 30:
+		return LevelInfo
+	case n >= 20:
+		return LevelDebug
+	case n >= 10:
+		return LevelTrace
+	default:
+		return LevelUnknown
+	}
+}
+
+func namedLevel(name string) Level {
+	switch {
+	case strings.HasPrefix(name, "trace"):
+		return LevelTrace
+	case strings.HasPrefix(name, "debug"):
+		return LevelDebug
+	case strings.HasPrefix(name, "info"), name == "notice":
+		return LevelInfo
+	case strings.HasPrefix(name, "warn"):
+		return LevelWarn
+	case strings.HasPrefix(name, "err"):
+		return LevelError
+	case strings.HasPrefix(name, "fatal"), strings.HasPrefix(name, "crit"),
+		name == "panic", name == "alert", name == "emergency":
+		return LevelFatal
+	default:
+		return LevelUnknown
+	}
+}
@@ -0,0 +1,26 @@
+package kail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelOf(t *testing.T) {
+	for value, expected := range map[interface{}]Level{
+		"INFO":      LevelInfo,
+		"warning":   LevelWarn,
+		"Err":       LevelError,
+		"CRITICAL":  LevelFatal,
+		"30":        LevelInfo,
+		float64(50): LevelError,
+		float64(10): LevelTrace,
+		"verbose":   LevelUnknown,
+		true:        LevelUnknown,
+	} {
+		assert.Equal(t, expected, LevelOf(value), "%v", value)
+	}
+
+	assert.Equal(t, LevelWarn, MessageLevel(map[string]interface{}{"severity": "WARNING"}))
+	assert.Equal(t, LevelUnknown, MessageLevel(map[string]interface{}{"msg": "hi"}))
+}
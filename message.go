@@ -0,0 +1,115 @@
+package kail
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ParseMessage decodes the fields of a structured log line.
+// Lines holding a JSON object or logfmt key/value pairs are supported.
+func ParseMessage(log []byte) (map[string]interface{}, bool) {
+	if fields, ok := parseJSONMessage(log); ok {
+		return fields, true
+	}
+	return ParseLogfmt(log)
+}
+
+func parseJSONMessage(log []byte) (map[string]interface{}, bool) {
+	log = bytes.TrimSpace(log)
+	if len(log) == 0 || log[0] != '{' {
+		return nil, false
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(log, &fields); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+// ParseLogfmt decodes a line of logfmt key/value pairs, such as
+//
+//	level=info msg="request complete" status=200
+//
+// Values are decoded as strings.  The line is rejected if any
+// of its words is not a key/value pair, so that free-form text
+// is not mistaken for logfmt.
+func ParseLogfmt(log []byte) (map[string]interface{}, bool) {
+	fields := map[string]interface{}{}
+
+	for log = bytes.TrimSpace(log); len(log) > 0; log = bytes.TrimLeft(log, " \t") {
+		key, value, rest, ok := scanLogfmtPair(log)
+		if !ok {
+			return nil, false
+		}
+		fields[key] = value
+		log = rest
+	}
+
+	if len(fields) == 0 {
+		return nil, false
+	}
+	return fields, true
+}
+
+func scanLogfmtPair(log []byte) (string, string, []byte, bool) {
+	eq := 0
+	for ; eq < len(log) && log[eq] != '='; eq++ {
+		if log[eq] <= ' ' || log[eq] == '"' {
+			return "", "", nil, false
+		}
+	}
+	if eq == 0 || eq == len(log) {
+		return "", "", nil, false
+	}
+
+	key := string(log[:eq])
+	log = log[eq+1:]
+
+	if len(log) > 0 && log[0] == '"' {
+		value, rest, ok := scanLogfmtQuoted(log)
+		return key, value, rest, ok
+	}
+
+	end := 0
+	for ; end < len(log) && log[end] > ' '; end++ {
+		if log[end] == '"' {
+			return "", "", nil, false
+		}
+	}
+	return key, string(log[:end]), log[end:], true
+}
+
+func scanLogfmtQuoted(log []byte) (string, []byte, bool) {
+	buf := make([]byte, 0, len(log))
+
+	for i := 1; i < len(log); i++ {
+		switch c := log[i]; c {
+		case '"':
+			rest := log[i+1:]
+			if len(rest) > 0 && rest[0] > ' ' {
+				return "", nil, false
+			}
+			return string(buf), rest, true
+		case '\\':
+			if i++; i == len(log) {
+				return "", nil, false
+			}
+			switch c = log[i]; c {
+			case 'n':
+				buf = append(buf, '\n')
+			case 't':
+				buf = append(buf, '\t')
+			case 'r':
+				buf = append(buf, '\r')
+			default:
+				buf = append(buf, c)
+			}
+		default:
+			buf = append(buf, c)
+		}
+	}
+
+	// unterminated quote
+	return "", nil, false
+}
@@ -0,0 +1,44 @@
+package kail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	fields, ok := ParseLogfmt([]byte(`level=info msg="request \"done\"" status=200 url=/a?b=c empty=`))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{
+		"level":  "info",
+		"msg":    `request "done"`,
+		"status": "200",
+		"url":    "/a?b=c",
+		"empty":  "",
+	}, fields)
+
+	for _, log := range []string{
+		"",
+		"starting server port=8080",
+		"level=info done",
+		`msg="unterminated`,
+		`msg="a"b`,
+		"=value",
+	} {
+		_, ok := ParseLogfmt([]byte(log))
+		assert.False(t, ok, log)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	fields, ok := ParseMessage([]byte(`{"level":"warn","n":1}`))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"level": "warn", "n": float64(1)}, fields)
+
+	fields, ok = ParseMessage([]byte(`level=warn n=1`))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"level": "warn", "n": "1"}, fields)
+
+	_, ok = ParseMessage([]byte(`{"level":`))
+	assert.False(t, ok)
+}
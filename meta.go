@@ -0,0 +1,111 @@
+package kail
+
+import (
+	"fmt"
+	"time"
+)
+
+// MetaKind identifies events that report on the sources being
+// followed rather than holding a log line.  See Event.Meta.
+type MetaKind int
+
+const (
+	// MetaNone is the kind of events holding a log line.
+	MetaNone MetaKind = iota
+
+	// MetaStarted reports that reading a container's logs started.
+	MetaStarted
+
+	// MetaReconnecting reports that a container's log stream
+	// ended and is being reopened.
+	MetaReconnecting
+
+	// MetaStreamError reports that reading a container's logs failed.
+	MetaStreamError
+
+	// MetaTerminated reports that a container terminated,
+	// along with its exit code and reason.
+	MetaTerminated
+
+	// MetaRestarted reports that a container was restarted.
+	MetaRestarted
+
+	// MetaPodDeleted reports that a pod was deleted.  The
+	// source of the event has no container.
+	MetaPodDeleted
+
+	// MetaDropped reports lines dropped because the event
+	// buffer was full.  See DeliveryNotify.
+	MetaDropped
+
+	// MetaKubernetesEvent is a Kubernetes Event involving a pod.
+	// See WithKubernetesEvents.
+	MetaKubernetesEvent
+
+	// MetaWaiting reports that a container is waiting for a log
+	// stream to close before its own opens.  See WithMaxStreams.
+	MetaWaiting
+)
+
+var metaKindNames = []string{
+	"", "started", "reconnecting", "error", "terminated", "restarted", "deleted", "dropped", "event",
+	"waiting",
+}
+
+func (k MetaKind) String() string {
+	if k < MetaNone || int(k) >= len(metaKindNames) {
+		return fmt.Sprintf("MetaKind(%d)", int(k))
+	}
+	return metaKindNames[k]
+}
+
+// newMetaEvent returns a meta event whose log is the formatted message.
+func newMetaEvent(source EventSource, kind MetaKind, level Level, format string, args ...interface{}) Event {
+	return &metaEvent{
+		source: source,
+		kind:   kind,
+		level:  level,
+		log:    []byte(fmt.Sprintf(format, args...)),
+		time:   time.Now(),
+	}
+}
+
+type metaEvent struct {
+	source EventSource
+	kind   MetaKind
+	level  Level
+	log    []byte
+	time   time.Time
+}
+
+func (e *metaEvent) Source() EventSource {
+	return e.source
+}
+
+func (e *metaEvent) Log() []byte {
+	return e.log
+}
+
+func (e *metaEvent) Time() time.Time {
+	return e.time
+}
+
+func (e *metaEvent) ContextBreak() bool {
+	return false
+}
+
+func (e *metaEvent) Level() Level {
+	return e.level
+}
+
+func (e *metaEvent) Fields() map[string]interface{} {
+	return nil
+}
+
+func (e *metaEvent) Partial() bool {
+	return false
+}
+
+func (e *metaEvent) Meta() MetaKind {
+	return e.kind
+}
@@ -5,20 +5,27 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	"k8s.io/client-go/rest"
 
 	lifecycle "github.com/boz/go-lifecycle"
 	logutil "github.com/boz/go-logutil"
 )
 
 const (
-	logBufsiz          = 1024 * 16 // 16k max message size
+	logBufsiz          = 1024 * 16   // 16k default read size
+	logMaxLineSize     = 1024 * 1024 // 1M default max line size
 	monitorDeliverWait = time.Millisecond
+
+	// number of times a stream that failed with a transient error
+	// is retried when not following.  following streams retry until
+	// the container goes away.
+	monitorMaxRetries = 5
 )
 
 var (
@@ -26,12 +33,38 @@ var (
 )
 
 type monitorConfig struct {
-	since time.Duration
+	since  time.Duration
+	follow bool
+
+	// number of lines to display from the end of the log when
+	// first connecting.  all lines are displayed if negative.
+	tail int64
+
+	// read the logs of the previous instance of the container.
+	previous bool
+
+	// cursor of an earlier monitor of the container to resume
+	// from, rather than displaying the logs given by since and tail.
+	resume *cursor
 }
 
 type monitor interface {
 	Shutdown()
 	Done() <-chan struct{}
+
+	// Err returns the error reading logs failed with, once done.
+	Err() error
+
+	// Started reports whether a log stream was opened, once done.
+	Started() bool
+
+	// Cursor returns a copy of the cursor of the last line read.
+	Cursor() *cursor
+
+	// SetTerminated reports whether the container is terminated.  A
+	// following monitor waits for a terminated container to restart
+	// rather than reconnecting once its logs have been read.
+	SetTerminated(terminated bool)
 }
 
 func newMonitor(c *controller, source EventSource, config monitorConfig) monitor {
@@ -42,13 +75,20 @@ func newMonitor(c *controller, source EventSource, config monitorConfig) monitor
 		fmt.Sprintf("monitor [%v]", source))
 
 	m := &_monitor{
-		rc:      c.rc,
-		source:  source,
-		config:  config,
-		eventch: c.eventch,
-		log:     log,
-		lc:      lc,
-		ctx:     c.ctx,
+		client:   c.cs.CoreV1(),
+		source:   source,
+		config:   config,
+		bufsiz:   c.readBufsiz,
+		maxLine:  c.maxLineSize,
+		truncate: c.truncateLines,
+		delivery: c.delivery,
+		failFast: c.failFast,
+		meta:     c.meta,
+		eventch:  c.eventch,
+		wakech:   make(chan struct{}, 1),
+		log:      log,
+		lc:       lc,
+		ctx:      c.ctx,
 	}
 
 	go m.run()
@@ -57,13 +97,36 @@ func newMonitor(c *controller, source EventSource, config monitorConfig) monitor
 }
 
 type _monitor struct {
-	rc      *rest.Config
+	client  corev1.CoreV1Interface
 	source  EventSource
 	config  monitorConfig
 	eventch chan<- Event
-	log     logutil.Log
-	lc      lifecycle.Lifecycle
-	ctx     context.Context
+
+	bufsiz   int
+	maxLine  int
+	truncate bool
+	delivery DeliveryMode
+
+	// fail on the first error rather than retrying transient ones.
+	failFast bool
+
+	// number of lines dropped and not yet reported.
+	dropped int
+
+	// deliver meta events; started is set once the first stream opens.
+	meta    bool
+	started bool
+
+	// cursor of the last line read, whether the container
+	// is terminated, and a signal that it changed.
+	mu         sync.Mutex
+	cur        *cursor
+	terminated bool
+	wakech     chan struct{}
+
+	log logutil.Log
+	lc  lifecycle.Lifecycle
+	ctx context.Context
 }
 
 func (m *_monitor) Shutdown() {
@@ -74,81 +137,221 @@ func (m *_monitor) Done() <-chan struct{} {
 	return m.lc.Done()
 }
 
+func (m *_monitor) Err() error {
+	return m.lc.Error()
+}
+
+func (m *_monitor) Started() bool {
+	return m.started
+}
+
+func (m *_monitor) Cursor() *cursor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cur == nil {
+		return newCursor()
+	}
+	return m.cur.copy()
+}
+
+func (m *_monitor) SetTerminated(terminated bool) {
+	m.mu.Lock()
+	changed := m.terminated != terminated
+	m.terminated = terminated
+	m.mu.Unlock()
+
+	if changed {
+		select {
+		case m.wakech <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (m *_monitor) isTerminated() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.terminated
+}
+
+// waitRunning waits for a terminated container to be running again.
+// It returns false if ctx is done first.
+func (m *_monitor) waitRunning(ctx context.Context) bool {
+	for m.isTerminated() {
+		select {
+		case <-m.wakech:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
 func (m *_monitor) run() {
 	defer m.log.Un(m.log.Trace("run"))
 	defer m.lc.ShutdownCompleted()
 
 	ctx, cancel := context.WithCancel(m.ctx)
 
-	client, err := m.makeClient(ctx)
-	if err != nil {
-		m.lc.ShutdownInitiated(err)
-		cancel()
-		return
-	}
-
 	donech := make(chan struct{})
 
-	go m.mainloop(ctx, client, donech)
+	go m.mainloop(ctx, m.client, donech)
 
-	err = <-m.lc.ShutdownRequest()
+	err := <-m.lc.ShutdownRequest()
 	m.lc.ShutdownInitiated(err)
 	cancel()
 
 	<-donech
 }
 
-func (m *_monitor) makeClient(ctx context.Context) (corev1.CoreV1Interface, error) {
-	cs, err := kubernetes.NewForConfig(m.rc)
-	if err != nil {
-		return nil, err
-	}
-	return cs.CoreV1(), nil
-}
-
 func (m *_monitor) mainloop(
 	ctx context.Context, client corev1.CoreV1Interface, donech chan struct{}) {
 	defer m.log.Un(m.log.Trace("mainloop"))
 	defer close(donech)
 
-	// todo: backoff handled by k8 client?
+	// report dropped lines even if the monitor is stopping.  the
+	// controller drains events until all monitors are done.
+	defer m.flushDropped(m.ctx)
+
+	opts := &v1.PodLogOptions{
+		Container:  m.source.Container(),
+		Follow:     m.config.follow,
+		Previous:   m.config.previous,
+		Timestamps: true,
+	}
+
+	if m.config.since > 0 {
+		sinceSecs := int64(math.Ceil(m.config.since.Seconds()))
+		opts.SinceSeconds = &sinceSecs
+		m.log.Debugf("displaying logs since %v seconds", sinceSecs)
+	}
+
+	if m.config.tail >= 0 {
+		tail := m.config.tail
+		opts.TailLines = &tail
+		m.log.Debugf("displaying last %v lines", tail)
+	}
+
+	cur := newCursor()
+	if resume := m.config.resume; resume != nil && !resume.time.IsZero() {
+		cur = resume.copy()
+		cur.resume()
+		resumeOptions(opts, cur.time)
+		m.log.Debugf("resuming logs since %v", cur.time)
+	}
 
-	sinceSecs := int64(m.config.since / time.Second)
-	since := &sinceSecs
+	m.mu.Lock()
+	m.cur = cur
+	m.mu.Unlock()
 
-	m.log.Debugf("displaying logs since %v seconds", sinceSecs)
+	retry := newBackoff()
+	retries := 0
 
 	for i := 0; ctx.Err() == nil; i++ {
 
 		m.log.Debugf("readloop count: %v", i)
 
-		err := m.readloop(ctx, client, since)
+		start := time.Now()
+		last := cur.time
+
+		err := m.readloop(ctx, client, opts, cur)
+
+		// delays start over once lines are read or a stream stays open.
+		productive := cur.time.After(last) || time.Since(start) > retry.max
+		if productive {
+			retry.reset()
+			retries = 0
+		}
+
+		var delay time.Duration
+
 		switch {
-		case err == io.EOF:
-		case err == nil:
 		case ctx.Err() != nil:
 			m.lc.ShutdownAsync(nil)
 			return
+		case err == io.EOF, err == nil:
+			if !m.config.follow {
+				m.lc.ShutdownAsync(nil)
+				return
+			}
+			switch {
+			case m.isTerminated():
+				// all logs have been read; wait for a restart.
+				m.flushDropped(ctx)
+				m.log.Debugf("container terminated; waiting for restart")
+				if !m.waitRunning(ctx) {
+					m.lc.ShutdownAsync(nil)
+					return
+				}
+				retry.reset()
+			case productive:
+				m.deliverMeta(ctx, MetaReconnecting, LevelInfo, "log stream ended; reconnecting")
+			default:
+				delay = retry.next(time.Now())
+			}
+		case isTransientError(err) && !m.failFast && (m.config.follow || retries < monitorMaxRetries):
+			retries++
+			delay = retry.next(time.Now())
+			m.log.Warnf("streaming failed; retrying in %v: %v", delay, err)
+			m.deliverMeta(ctx, MetaReconnecting, LevelWarn,
+				"log stream failed: %v; reconnecting in %v", err, delay.Round(time.Millisecond))
 		default:
 			m.log.ErrWarn(err, "streaming done")
+			m.deliverMeta(ctx, MetaStreamError, LevelError, "%v", err)
 			m.lc.ShutdownAsync(err)
 			return
 		}
-		sinceSecs = 1
+
+		m.flushDropped(ctx)
+
+		if delay > 0 {
+			m.log.Debugf("reconnecting in %v", delay)
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-m.wakech:
+				// the container's state changed; reconnect to find out more.
+				t.Stop()
+			case <-ctx.Done():
+				t.Stop()
+				m.lc.ShutdownAsync(nil)
+				return
+			}
+		}
+
+		if !m.started {
+			// no stream was opened; retry the original request.
+			continue
+		}
+
+		// resume from the last line seen.  if no lines were seen,
+		// resume from when the previous stream was opened.
+		since := cur.time
+		if since.IsZero() {
+			since = start
+		}
+
+		m.mu.Lock()
+		cur.resume()
+		m.mu.Unlock()
+
+		m.log.Debugf("resuming logs since %v", since)
+		resumeOptions(opts, since)
 	}
 }
 
+// resumeOptions requests the logs written since the given time.
+func resumeOptions(opts *v1.PodLogOptions, since time.Time) {
+	opts.SinceSeconds = nil
+	opts.SinceTime = &metav1.Time{Time: since}
+	opts.TailLines = nil
+}
+
 func (m *_monitor) readloop(
-	ctx context.Context, client corev1.CoreV1Interface, since *int64) error {
+	ctx context.Context, client corev1.CoreV1Interface, opts *v1.PodLogOptions, cur *cursor) error {
 
 	defer m.log.Un(m.log.Trace("readloop"))
 
-	opts := &v1.PodLogOptions{
-		Container:    m.source.Container(),
-		Follow:       true,
-		SinceSeconds: since,
-	}
-
 	req := client.
 		Pods(m.source.Namespace()).
 		GetLogs(m.source.Name(), opts)
@@ -160,12 +363,33 @@ func (m *_monitor) readloop(
 
 	defer stream.Close()
 
-	logbuf := make([]byte, logBufsiz)
-	buffer := newBuffer(m.source)
+	if !m.started {
+		m.started = true
+		switch {
+		case m.config.previous:
+			m.deliverMeta(ctx, MetaStarted, LevelInfo, "reading logs of previous instance")
+		case m.config.follow:
+			m.deliverMeta(ctx, MetaStarted, LevelInfo, "following logs")
+		default:
+			m.deliverMeta(ctx, MetaStarted, LevelInfo, "reading logs")
+		}
+	}
+
+	logbuf := make([]byte, m.bufsiz)
+	buffer := newBuffer(m.source, m.maxLine, m.truncate)
 
 	for ctx.Err() == nil {
 		nread, err := stream.Read(logbuf)
 
+		// the last bytes of a stream may be read along with io.EOF.
+		if log := logbuf[0:nread]; nread > 0 && ctx.Err() == nil {
+			if bytes.Equal(canaryLog, log) {
+				m.log.Debugf("received 'unexpect stream type'")
+			} else if events := m.filter(cur, buffer.process(log)); len(events) > 0 {
+				m.deliverEvents(ctx, events)
+			}
+		}
+
 		switch {
 		case err == io.EOF:
 			return err
@@ -176,34 +400,148 @@ func (m *_monitor) readloop(
 		case nread == 0:
 			return io.EOF
 		}
-
-		log := logbuf[0:nread]
-
-		if bytes.Equal(canaryLog, log) {
-			m.log.Debugf("received 'unexpect stream type'")
-			continue
-		}
-
-		if events := buffer.process(log); len(events) > 0 {
-			m.deliverEvents(ctx, events)
-		}
-
 	}
 	return nil
 }
 
+// filter returns the events not already seen, advancing the cursor.
+func (m *_monitor) filter(cur *cursor, events []Event) []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return cur.filter(events)
+}
+
 func (m *_monitor) deliverEvents(ctx context.Context, events []Event) {
+	if m.delivery == DeliveryBlock {
+		for _, event := range events {
+			select {
+			case m.eventch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		return
+	}
+
 	t := time.NewTimer(monitorDeliverWait)
 	defer t.Stop()
 
+	if m.dropped > 0 {
+		select {
+		case m.eventch <- m.droppedEvent():
+			m.dropped = 0
+		case <-t.C:
+			m.log.Warnf("event buffer full. dropping %v logs", len(events))
+			m.dropped += len(events)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+
 	for i, event := range events {
 		select {
 		case m.eventch <- event:
 		case <-t.C:
 			m.log.Warnf("event buffer full. dropping %v logs", len(events)-i)
+			if m.delivery == DeliveryNotify {
+				m.dropped += len(events) - i
+			}
 			return
 		case <-ctx.Done():
 			return
 		}
 	}
 }
+
+// droppedEvent reports the lines dropped since the last report.
+func (m *_monitor) droppedEvent() Event {
+	return newMetaEvent(m.source, MetaDropped, LevelWarn, "%v lines dropped", m.dropped)
+}
+
+// flushDropped reports lines dropped and not yet reported,
+// waiting for room in the event buffer.
+func (m *_monitor) flushDropped(ctx context.Context) {
+	if m.dropped == 0 {
+		return
+	}
+	select {
+	case m.eventch <- m.droppedEvent():
+		m.dropped = 0
+	case <-ctx.Done():
+	}
+}
+
+// deliverMeta delivers a meta event if they are enabled.
+func (m *_monitor) deliverMeta(
+	ctx context.Context, kind MetaKind, level Level, format string, args ...interface{}) {
+	if m.meta {
+		m.deliverEvents(ctx, []Event{newMetaEvent(m.source, kind, level, format, args...)})
+	}
+}
+
+// cursor tracks the timestamp of the last line read from a container's
+// log stream so that a new stream can resume where the last one left off.
+//
+// kubelet only honors the resume time to the second, so lines at or before
+// the cursor are replayed by the new stream.  these are dropped until a line
+// newer than the cursor is read.
+type cursor struct {
+	time      time.Time
+	lines     map[string]bool
+	replaying bool
+}
+
+func newCursor() *cursor {
+	return &cursor{lines: make(map[string]bool)}
+}
+
+func (c *cursor) copy() *cursor {
+	lines := make(map[string]bool, len(c.lines))
+	for line := range c.lines {
+		lines[line] = true
+	}
+	return &cursor{time: c.time, lines: lines, replaying: c.replaying}
+}
+
+// resume prepares the cursor for a new stream.
+func (c *cursor) resume() {
+	c.replaying = !c.time.IsZero()
+}
+
+// filter records the given events and returns those that have not
+// already been seen.
+func (c *cursor) filter(events []Event) []Event {
+	result := events[:0]
+	for _, ev := range events {
+		if c.advance(ev) {
+			result = append(result, ev)
+		}
+	}
+	return result
+}
+
+func (c *cursor) advance(ev Event) bool {
+	t := ev.Time()
+
+	switch {
+	case t.IsZero():
+		return !c.replaying
+	case t.After(c.time):
+		c.time = t
+		c.lines = make(map[string]bool)
+		c.replaying = false
+	case t.Equal(c.time):
+		if c.replaying && c.lines[string(ev.Log())] {
+			return false
+		}
+	case c.replaying:
+		return false
+	default:
+		// out of order line; nothing to track.
+		return true
+	}
+
+	c.lines[string(ev.Log())] = true
+	return true
+}
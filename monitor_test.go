@@ -0,0 +1,269 @@
+package kail
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lifecycle "github.com/boz/go-lifecycle"
+	logutil "github.com/boz/go-logutil"
+	"github.com/boz/kcache/nsname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestCursor(t *testing.T) {
+	source := eventSource{}
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	t1 := t0.Add(time.Millisecond)
+	t2 := t1.Add(time.Millisecond)
+
+	cur := newCursor()
+
+	events := cur.filter([]Event{
+		newEvent(source, []byte("a"), t0),
+		newEvent(source, []byte("b"), t1),
+		newEvent(source, []byte("c"), t1),
+	})
+	assert.Len(t, events, 3)
+
+	cur.resume()
+
+	// replayed lines are dropped until a newer line is seen.
+	events = cur.filter([]Event{
+		newEvent(source, []byte("a"), t0),
+		newEvent(source, []byte("b"), t1),
+		newEvent(source, []byte("d"), t1),
+		newEvent(source, []byte("c"), t1),
+		newEvent(source, []byte("e"), t2),
+		newEvent(source, []byte("e"), t2),
+	})
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, "d", string(events[0].Log()))
+		assert.Equal(t, "e", string(events[1].Log()))
+		assert.Equal(t, "e", string(events[2].Log()))
+	}
+}
+
+func TestDeliverEventsNotify(t *testing.T) {
+	ctx := context.Background()
+	source := eventSource{id: nsname.New("ns", "pod"), container: "c"}
+	eventch := make(chan Event, 1)
+
+	m := &_monitor{
+		source:   source,
+		eventch:  eventch,
+		delivery: DeliveryNotify,
+		log:      logutil.FromContextOrDefault(ctx),
+	}
+
+	m.deliverEvents(ctx, []Event{
+		newEvent(source, []byte("a"), time.Time{}),
+		newEvent(source, []byte("b"), time.Time{}),
+		newEvent(source, []byte("c"), time.Time{}),
+	})
+	assert.Equal(t, "a", string((<-eventch).Log()))
+	assert.Equal(t, 2, m.dropped)
+
+	m.deliverEvents(ctx, []Event{newEvent(source, []byte("d"), time.Time{})})
+	assert.Equal(t, "2 lines dropped", string((<-eventch).Log()))
+	assert.Equal(t, 1, m.dropped)
+}
+
+func TestMonitorReportsDroppedAtEnd(t *testing.T) {
+	handled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(handled)
+		fmt.Fprint(w, "a\nb\nc\n")
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	source := eventSource{id: nsname.New("ns", "pod"), container: "c"}
+
+	// the buffer is full until the stream has ended.
+	eventch := make(chan Event, 1)
+	eventch <- newEvent(source, []byte("full"), time.Time{})
+
+	m := &_monitor{
+		client:   cs.CoreV1(),
+		source:   source,
+		config:   monitorConfig{tail: -1},
+		eventch:  eventch,
+		bufsiz:   logBufsiz,
+		maxLine:  logMaxLineSize,
+		delivery: DeliveryNotify,
+		log:      logutil.FromContextOrDefault(ctx),
+		lc:       lifecycle.New(),
+		ctx:      ctx,
+	}
+	go m.run()
+
+	<-handled
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, "full", string((<-eventch).Log()))
+
+	select {
+	case ev := <-eventch:
+		assert.Equal(t, MetaDropped, ev.Meta())
+		assert.Equal(t, "3 lines dropped", string(ev.Log()))
+	case <-m.Done():
+		t.Fatal("monitor done without reporting dropped lines")
+	}
+
+	<-m.Done()
+	assert.NoError(t, m.Err())
+}
+
+func TestMonitorWaitsForTerminatedContainer(t *testing.T) {
+	requests := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v line\n", time.Now().UTC().Format(time.RFC3339Nano))
+		requests <- struct{}{}
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	m := &_monitor{
+		client:  cs.CoreV1(),
+		source:  eventSource{id: nsname.New("ns", "pod"), container: "c"},
+		config:  monitorConfig{follow: true, tail: -1},
+		eventch: make(chan Event, 10),
+		bufsiz:  logBufsiz,
+		maxLine: logMaxLineSize,
+		wakech:  make(chan struct{}, 1),
+		log:     logutil.FromContextOrDefault(ctx),
+		lc:      lifecycle.New(),
+		ctx:     ctx,
+	}
+	m.SetTerminated(true)
+	go m.run()
+	defer func() {
+		m.Shutdown()
+		<-m.Done()
+	}()
+
+	<-requests
+	select {
+	case <-requests:
+		t.Fatal("reconnected to a terminated container")
+	case <-time.After(backoffMin * 2):
+	}
+
+	m.SetTerminated(false)
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not reconnect once the container restarted")
+	}
+}
+
+func TestMonitorResumesFromCursor(t *testing.T) {
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	t1 := t0.Add(time.Millisecond)
+
+	queries := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		fmt.Fprintf(w, "%v a\n", t0.Format(time.RFC3339Nano))
+		fmt.Fprintf(w, "%v b\n", t1.Format(time.RFC3339Nano))
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	source := eventSource{id: nsname.New("ns", "pod"), container: "c"}
+
+	// the cursor of a monitor that read the first line before failing.
+	resume := newCursor()
+	resume.filter([]Event{newEvent(source, []byte("a"), t0)})
+
+	eventch := make(chan Event, 10)
+	m := &_monitor{
+		client:  cs.CoreV1(),
+		source:  source,
+		config:  monitorConfig{tail: 10, resume: resume},
+		eventch: eventch,
+		bufsiz:  logBufsiz,
+		maxLine: logMaxLineSize,
+		wakech:  make(chan struct{}, 1),
+		log:     logutil.FromContextOrDefault(ctx),
+		lc:      lifecycle.New(),
+		ctx:     ctx,
+	}
+	go m.run()
+
+	select {
+	case <-m.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("monitor did not complete")
+	}
+
+	query := <-queries
+	assert.Equal(t, t0.Format(time.RFC3339), query.Get("sinceTime"))
+	assert.Empty(t, query.Get("tailLines"))
+
+	close(eventch)
+	var lines []string
+	for ev := range eventch {
+		lines = append(lines, string(ev.Log()))
+	}
+	assert.Equal(t, []string{"b"}, lines)
+
+	assert.Equal(t, t1, m.Cursor().time)
+}
+
+func TestMonitorFailFast(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	m := &_monitor{
+		client:   cs.CoreV1(),
+		source:   eventSource{id: nsname.New("ns", "pod"), container: "c"},
+		config:   monitorConfig{follow: true, tail: -1},
+		eventch:  make(chan Event, 10),
+		bufsiz:   logBufsiz,
+		maxLine:  logMaxLineSize,
+		failFast: true,
+		wakech:   make(chan struct{}, 1),
+		log:      logutil.FromContextOrDefault(ctx),
+		lc:       lifecycle.New(),
+		ctx:      ctx,
+	}
+	go m.run()
+
+	select {
+	case <-m.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("retried a transient error")
+	}
+
+	assert.True(t, isTransientError(m.Err()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
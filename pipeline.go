@@ -0,0 +1,406 @@
+package kail
+
+import (
+	"bytes"
+	"container/heap"
+	"sort"
+	"time"
+
+	"github.com/boz/kcache/nsname"
+)
+
+const (
+	// how often stages holding events are checked for ready events.
+	pipelineTickInterval = 50 * time.Millisecond
+
+	// maximum number of lines joined into a single event.
+	joinMaxLines = 1000
+
+	// how long the context of a source is kept after its last line.
+	contextIdleTimeout = 10 * time.Minute
+)
+
+// stage is a step in the pipeline that events pass through
+// on their way from the monitors to Controller.Events().
+// Stages that filter or combine lines pass meta events through.
+type stage interface {
+	// process consumes an event and returns the events
+	// that are ready to be passed to the next stage.
+	process(ev Event) []Event
+}
+
+// timedStage is a stage that holds events back for a time.
+type timedStage interface {
+	stage
+
+	// tick returns the held events that are ready as of now.
+	tick(now time.Time) []Event
+
+	// flush returns all held events.
+	flush() []Event
+}
+
+type pipeline []stage
+
+func (p pipeline) process(ev Event) []Event {
+	return p.apply([]Event{ev}, nil)
+}
+
+func (p pipeline) tick(now time.Time) []Event {
+	return p.apply(nil, func(s timedStage) []Event {
+		return s.tick(now)
+	})
+}
+
+func (p pipeline) flush() []Event {
+	return p.apply(nil, timedStage.flush)
+}
+
+// timed reports whether any stage holds events back.
+func (p pipeline) timed() bool {
+	for _, s := range p {
+		if _, ok := s.(timedStage); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// apply passes events through each stage in turn.  if release is
+// given, events released by timed stages are passed on as well.
+func (p pipeline) apply(events []Event, release func(timedStage) []Event) []Event {
+	for _, s := range p {
+		var next []Event
+		for _, ev := range events {
+			next = append(next, s.process(ev)...)
+		}
+		if ts, ok := s.(timedStage); ok && release != nil {
+			next = append(next, release(ts)...)
+		}
+		events = next
+	}
+	return events
+}
+
+type lineFilterStage struct {
+	filter LineFilter
+}
+
+func (s lineFilterStage) process(ev Event) []Event {
+	if ev.Meta() != MetaNone || s.filter.Accept(ev.Log()) {
+		return []Event{ev}
+	}
+	return nil
+}
+
+type eventFilterStage struct {
+	filter EventFilter
+}
+
+func (s eventFilterStage) process(ev Event) []Event {
+	if ev.Meta() != MetaNone || s.filter.Accept(ev) {
+		return []Event{ev}
+	}
+	return nil
+}
+
+// levelFilterStage drops events below a minimum level.
+type levelFilterStage struct {
+	min           Level
+	dropUnleveled bool
+}
+
+func (s levelFilterStage) process(ev Event) []Event {
+	switch level := ev.Level(); {
+	case ev.Meta() != MetaNone:
+	case level == LevelUnknown && s.dropUnleveled:
+		return nil
+	case level != LevelUnknown && level < s.min:
+		return nil
+	}
+	return []Event{ev}
+}
+
+// joinStage joins the lines of multi-line records from each source
+// into single events.  A record is released when the next record from
+// its source starts, or when no lines have arrived for the timeout.
+type joinStage struct {
+	joiner  LineJoiner
+	timeout time.Duration
+	records map[eventSource]*joinRecord
+	seq     uint64
+	now     func() time.Time
+}
+
+type joinRecord struct {
+	first Event
+	lines [][]byte
+	seq   uint64
+	last  time.Time
+}
+
+func newJoinStage(joiner LineJoiner, timeout time.Duration) *joinStage {
+	return &joinStage{
+		joiner:  joiner,
+		timeout: timeout,
+		records: make(map[eventSource]*joinRecord),
+		now:     time.Now,
+	}
+}
+
+func (s *joinStage) process(ev Event) []Event {
+	if ev.Meta() != MetaNone {
+		return []Event{ev}
+	}
+
+	key := sourceKey(ev.Source())
+	now := s.now()
+
+	record, ok := s.records[key]
+	if ok && len(record.lines) < joinMaxLines && s.joiner.Continues(ev.Log()) {
+		record.lines = append(record.lines, ev.Log())
+		record.last = now
+		return nil
+	}
+
+	s.seq++
+	s.records[key] = &joinRecord{
+		first: ev,
+		lines: [][]byte{ev.Log()},
+		seq:   s.seq,
+		last:  now,
+	}
+
+	if ok {
+		return []Event{record.event()}
+	}
+	return nil
+}
+
+func (s *joinStage) tick(now time.Time) []Event {
+	return s.release(func(record *joinRecord) bool {
+		return !record.last.Add(s.timeout).After(now)
+	})
+}
+
+func (s *joinStage) flush() []Event {
+	return s.release(func(*joinRecord) bool {
+		return true
+	})
+}
+
+// release returns the records accepted by ready
+// in the order that they were started.
+func (s *joinStage) release(ready func(*joinRecord) bool) []Event {
+	var records []*joinRecord
+	for key, record := range s.records {
+		if ready(record) {
+			records = append(records, record)
+			delete(s.records, key)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].seq < records[j].seq
+	})
+
+	events := make([]Event, 0, len(records))
+	for _, record := range records {
+		events = append(events, record.event())
+	}
+	return events
+}
+
+func (r *joinRecord) event() Event {
+	if len(r.lines) == 1 {
+		return r.first
+	}
+	return newEvent(r.first.Source(), bytes.Join(r.lines, []byte("\n")), r.first.Time())
+}
+
+// contextStage forwards events accepted by the filter along with up to
+// before and after surrounding lines from the same source.  The context
+// of sources that have not logged for contextIdleTimeout is dropped.
+type contextStage struct {
+	filter  LineFilter
+	before  int
+	after   int
+	sources map[eventSource]*sourceContext
+	now     func() time.Time
+}
+
+type sourceContext struct {
+	// lines preceding the next match; at most before are retained.
+	lines []Event
+
+	// sequence number of the last line seen and forwarded.
+	seen      uint64
+	forwarded uint64
+
+	// number of lines after the last match still to be forwarded.
+	remaining int
+
+	// time the last line was seen.
+	last time.Time
+}
+
+func newContextStage(filter LineFilter, before, after int) *contextStage {
+	return &contextStage{
+		filter:  filter,
+		before:  before,
+		after:   after,
+		sources: make(map[eventSource]*sourceContext),
+		now:     time.Now,
+	}
+}
+
+func (s *contextStage) process(ev Event) []Event {
+	if ev.Meta() != MetaNone {
+		return []Event{ev}
+	}
+
+	key := sourceKey(ev.Source())
+
+	sc, ok := s.sources[key]
+	if !ok {
+		sc = &sourceContext{}
+		s.sources[key] = sc
+	}
+
+	sc.seen++
+	sc.last = s.now()
+
+	switch {
+	case s.filter.Accept(ev.Log()):
+		events := append(sc.lines, ev)
+		sc.lines = nil
+		sc.remaining = s.after
+		return sc.forward(events)
+	case sc.remaining > 0:
+		sc.remaining--
+		return sc.forward([]Event{ev})
+	case s.before > 0:
+		if len(sc.lines) == s.before {
+			sc.lines = sc.lines[1:]
+		}
+		sc.lines = append(sc.lines, ev)
+	}
+	return nil
+}
+
+// tick drops the context of idle sources, such as containers
+// that have stopped.  it holds no events back.
+func (s *contextStage) tick(now time.Time) []Event {
+	for key, sc := range s.sources {
+		if !sc.last.Add(contextIdleTimeout).After(now) {
+			delete(s.sources, key)
+		}
+	}
+	return nil
+}
+
+func (s *contextStage) flush() []Event {
+	return nil
+}
+
+// forward marks the first of the given events if it is not
+// contiguous with the last event forwarded.
+func (sc *sourceContext) forward(events []Event) []Event {
+	first := sc.seen - uint64(len(events)) + 1
+	if sc.forwarded > 0 && first != sc.forwarded+1 {
+		events[0] = contextBreakEvent{events[0]}
+	}
+	sc.forwarded = sc.seen
+	return events
+}
+
+// sourceKey returns a comparable key identifying the container
+// that the source refers to.
+func sourceKey(source EventSource) eventSource {
+	return eventSource{
+		id:        nsname.New(source.Namespace(), source.Name()),
+		container: source.Container(),
+		node:      source.Node(),
+		previous:  source.Previous(),
+	}
+}
+
+// reorderStage holds events for a window of time and
+// releases them ordered by the time they were logged.
+type reorderStage struct {
+	window time.Duration
+	queue  reorderQueue
+	seq    uint64
+	now    func() time.Time
+}
+
+func newReorderStage(window time.Duration) *reorderStage {
+	return &reorderStage{window: window, now: time.Now}
+}
+
+func (s *reorderStage) process(ev Event) []Event {
+	now := s.now()
+
+	key := ev.Time()
+	if key.IsZero() {
+		key = now
+	}
+
+	s.seq++
+	heap.Push(&s.queue, reorderItem{
+		event: ev,
+		key:   key,
+		seq:   s.seq,
+		ready: now.Add(s.window),
+	})
+	return nil
+}
+
+func (s *reorderStage) tick(now time.Time) []Event {
+	var events []Event
+	for len(s.queue) > 0 && !s.queue[0].ready.After(now) {
+		events = append(events, heap.Pop(&s.queue).(reorderItem).event)
+	}
+	return events
+}
+
+func (s *reorderStage) flush() []Event {
+	events := make([]Event, 0, len(s.queue))
+	for len(s.queue) > 0 {
+		events = append(events, heap.Pop(&s.queue).(reorderItem).event)
+	}
+	return events
+}
+
+type reorderItem struct {
+	event Event
+	key   time.Time
+	seq   uint64
+	ready time.Time
+}
+
+// reorderQueue is a heap of events ordered by time logged
+// and then by order received.
+type reorderQueue []reorderItem
+
+func (q reorderQueue) Len() int { return len(q) }
+
+func (q reorderQueue) Less(i, j int) bool {
+	if q[i].key.Equal(q[j].key) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].key.Before(q[j].key)
+}
+
+func (q reorderQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *reorderQueue) Push(x interface{}) {
+	*q = append(*q, x.(reorderItem))
+}
+
+func (q *reorderQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,140 @@
+package kail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boz/kcache/nsname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextStage(t *testing.T) {
+	filter, err := NewLineFilter([]string{"match"}, nil, false)
+	assert.NoError(t, err)
+
+	a := eventSource{id: nsname.New("ns", "a")}
+	b := eventSource{id: nsname.New("ns", "b")}
+
+	stage := newContextStage(filter, 1, 1)
+
+	var events []Event
+	for _, ev := range []Event{
+		newEvent(a, []byte("a1"), time.Time{}),
+		newEvent(b, []byte("b1"), time.Time{}),
+		newEvent(a, []byte("a2 match"), time.Time{}),
+		newEvent(b, []byte("b2 match"), time.Time{}),
+		newEvent(a, []byte("a3"), time.Time{}),
+		newEvent(a, []byte("a4"), time.Time{}),
+		newEvent(a, []byte("a5"), time.Time{}),
+		newEvent(a, []byte("a6 match"), time.Time{}),
+		newEvent(a, []byte("a7 match"), time.Time{}),
+	} {
+		events = append(events, stage.process(ev)...)
+	}
+
+	var logs []string
+	var breaks []bool
+	for _, ev := range events {
+		logs = append(logs, string(ev.Log()))
+		breaks = append(breaks, ev.ContextBreak())
+	}
+
+	assert.Equal(t,
+		[]string{"a1", "a2 match", "b1", "b2 match", "a3", "a5", "a6 match", "a7 match"}, logs)
+	assert.Equal(t,
+		[]bool{false, false, false, false, false, true, false, false}, breaks)
+}
+
+func TestContextStageExpiresIdleSources(t *testing.T) {
+	filter, err := NewLineFilter([]string{"match"}, nil, false)
+	assert.NoError(t, err)
+
+	a := eventSource{id: nsname.New("ns", "a")}
+	b := eventSource{id: nsname.New("ns", "b")}
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	now := t0
+	stage := newContextStage(filter, 1, 0)
+	stage.now = func() time.Time { return now }
+
+	assert.Empty(t, stage.process(newEvent(a, []byte("a1"), time.Time{})))
+
+	now = t0.Add(contextIdleTimeout / 2)
+	assert.Empty(t, stage.process(newEvent(b, []byte("b1"), time.Time{})))
+
+	assert.Empty(t, stage.tick(t0.Add(contextIdleTimeout-time.Second)))
+	assert.Len(t, stage.sources, 2)
+
+	assert.Empty(t, stage.tick(t0.Add(contextIdleTimeout)))
+	assert.Len(t, stage.sources, 1)
+	assert.Contains(t, stage.sources, b)
+
+	// the context of a was dropped along with it.
+	events := stage.process(newEvent(a, []byte("a2 match"), time.Time{}))
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "a2 match", string(events[0].Log()))
+	}
+
+	assert.Empty(t, stage.flush())
+}
+
+func TestReorderStage(t *testing.T) {
+	source := eventSource{}
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	now := t0
+	stage := newReorderStage(time.Second)
+	stage.now = func() time.Time { return now }
+
+	assert.Empty(t, stage.process(newEvent(source, []byte("b"), t0.Add(2))))
+	assert.Empty(t, stage.process(newEvent(source, []byte("a"), t0.Add(1))))
+
+	now = now.Add(time.Second / 2)
+	assert.Empty(t, stage.process(newEvent(source, []byte("c"), t0.Add(3))))
+	assert.Empty(t, stage.tick(now))
+
+	var logs []string
+	for _, ev := range stage.tick(t0.Add(time.Second)) {
+		logs = append(logs, string(ev.Log()))
+	}
+	assert.Equal(t, []string{"a", "b"}, logs)
+
+	logs = nil
+	for _, ev := range stage.flush() {
+		logs = append(logs, string(ev.Log()))
+	}
+	assert.Equal(t, []string{"c"}, logs)
+}
+
+func TestJoinStage(t *testing.T) {
+	a := eventSource{id: nsname.New("ns", "a")}
+	b := eventSource{id: nsname.New("ns", "b")}
+	t0 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	now := t0
+	stage := newJoinStage(NewIndentJoiner(), time.Second)
+	stage.now = func() time.Time { return now }
+
+	logs := func(events []Event) []string {
+		var logs []string
+		for _, ev := range events {
+			logs = append(logs, string(ev.Log()))
+		}
+		return logs
+	}
+
+	assert.Empty(t, stage.process(newEvent(a, []byte("Exception: failed"), t0)))
+	assert.Empty(t, stage.process(newEvent(b, []byte("b1"), t0)))
+	assert.Empty(t, stage.process(newEvent(a, []byte("\tat Main.run"), t0.Add(1))))
+	assert.Empty(t, stage.process(newEvent(a, []byte("\tat Main.main"), t0.Add(2))))
+
+	events := stage.process(newEvent(a, []byte("a2"), t0.Add(3)))
+	assert.Equal(t, []string{"Exception: failed\n\tat Main.run\n\tat Main.main"}, logs(events))
+	assert.Equal(t, t0, events[0].Time())
+
+	now = now.Add(time.Second / 2)
+	assert.Empty(t, stage.process(newEvent(b, []byte("  b1 continued"), t0.Add(4))))
+
+	assert.Equal(t, []string{"a2"}, logs(stage.tick(t0.Add(time.Second))))
+	assert.Equal(t, []string{"b1\n  b1 continued"}, logs(stage.flush()))
+}
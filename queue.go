@@ -0,0 +1,114 @@
+package kail
+
+import (
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// streamQueue holds the sources waiting for a log stream
+// when the controller's maximum number of streams are open.
+type streamQueue struct {
+	// pods matching any of prefer are streamed first.
+	prefer []labels.Selector
+
+	// stream the most recently created pods first instead
+	// of in the order that the sources were queued.
+	newestFirst bool
+
+	items []*pendingStream
+	seq   uint64
+}
+
+type pendingStream struct {
+	source    eventSource
+	labels    map[string]string
+	config    monitorConfig
+	created   time.Time
+	preferred bool
+	seq       uint64
+}
+
+// push queues source of pod unless it is already queued.
+func (q *streamQueue) push(pod *v1.Pod, source eventSource, config monitorConfig) {
+	if q.contains(source) {
+		return
+	}
+
+	preferred := false
+	for _, selector := range q.prefer {
+		if selector.Matches(labels.Set(pod.GetLabels())) {
+			preferred = true
+			break
+		}
+	}
+
+	q.seq++
+	q.items = append(q.items, &pendingStream{
+		source:    source,
+		labels:    pod.GetLabels(),
+		config:    config,
+		created:   pod.GetCreationTimestamp().Time,
+		preferred: preferred,
+		seq:       q.seq,
+	})
+}
+
+// pop removes and returns the queued source of highest priority.
+func (q *streamQueue) pop() (*pendingStream, bool) {
+	if len(q.items) == 0 {
+		return nil, false
+	}
+
+	next := 0
+	for i := 1; i < len(q.items); i++ {
+		if q.before(q.items[i], q.items[next]) {
+			next = i
+		}
+	}
+
+	item := q.items[next]
+	q.items = append(q.items[:next], q.items[next+1:]...)
+	return item, true
+}
+
+// before reports whether a is streamed before b.
+func (q *streamQueue) before(a, b *pendingStream) bool {
+	switch {
+	case a.preferred != b.preferred:
+		return a.preferred
+	case q.newestFirst && !a.created.Equal(b.created):
+		return a.created.After(b.created)
+	default:
+		return a.seq < b.seq
+	}
+}
+
+func (q *streamQueue) contains(source eventSource) bool {
+	for _, item := range q.items {
+		if item.source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// remove drops the queued sources accepted by fn.
+func (q *streamQueue) remove(fn func(eventSource) bool) {
+	items := q.items[:0]
+	for _, item := range q.items {
+		if !fn(item.source) {
+			items = append(items, item)
+		}
+	}
+	q.items = items
+}
+
+func (q *streamQueue) clear() {
+	q.items = nil
+}
+
+func (q *streamQueue) len() int {
+	return len(q.items)
+}
@@ -0,0 +1,76 @@
+package kail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boz/kcache/nsname"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestStreamQueue(t *testing.T) {
+	now := time.Now()
+
+	makePod := func(name string, age time.Duration, lbls map[string]string) (*v1.Pod, eventSource) {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "ns",
+			Name:              name,
+			Labels:            lbls,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		}}
+		return pod, eventSource{id: nsname.New("ns", name), container: "app"}
+	}
+
+	order := func(q *streamQueue) []string {
+		var names []string
+		for {
+			item, ok := q.pop()
+			if !ok {
+				return names
+			}
+			names = append(names, item.source.id.Name)
+		}
+	}
+
+	fill := func(q *streamQueue) {
+		for _, spec := range []struct {
+			name string
+			age  time.Duration
+			app  string
+		}{
+			{"old", time.Hour, "web"},
+			{"new", time.Minute, "web"},
+			{"api", 2 * time.Hour, "api"},
+			{"mid", 10 * time.Minute, "web"},
+		} {
+			pod, source := makePod(spec.name, spec.age, map[string]string{"app": spec.app})
+			q.push(pod, source, monitorConfig{})
+			q.push(pod, source, monitorConfig{})
+		}
+	}
+
+	{
+		q := &streamQueue{}
+		fill(q)
+		assert.Equal(t, 4, q.len())
+		assert.Equal(t, []string{"old", "new", "api", "mid"}, order(q))
+	}
+
+	{
+		q := &streamQueue{newestFirst: true}
+		fill(q)
+		assert.Equal(t, []string{"new", "mid", "old", "api"}, order(q))
+	}
+
+	{
+		q := &streamQueue{newestFirst: true, prefer: []labels.Selector{labels.SelectorFromSet(labels.Set{"app": "api"})}}
+		fill(q)
+		q.remove(func(source eventSource) bool {
+			return source.id.Name == "mid"
+		})
+		assert.Equal(t, []string{"api", "new", "old"}, order(q))
+	}
+}
@@ -2,6 +2,8 @@ package kail
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/boz/kcache/nsname"
 )
@@ -11,12 +13,20 @@ type EventSource interface {
 	Name() string
 	Container() string
 	Node() string
+
+	// Previous reports whether the source is the previous,
+	// terminated instance of the container.
+	Previous() bool
+
+	// Labels returns the labels of the pod, if known.
+	Labels() map[string]string
 }
 
 type eventSource struct {
 	id        nsname.NSName
 	container string
 	node      string
+	previous  bool
 }
 
 func (es eventSource) Namespace() string {
@@ -35,23 +45,86 @@ func (es eventSource) Node() string {
 	return es.node
 }
 
+func (es eventSource) Previous() bool {
+	return es.previous
+}
+
+func (es eventSource) Labels() map[string]string {
+	return nil
+}
+
 func (es eventSource) String() string {
+	if es.previous {
+		return fmt.Sprintf("%v/%v@%v(previous)",
+			es.id.Namespace, es.id.Name, es.container)
+	}
 	return fmt.Sprintf("%v/%v@%v",
 		es.id.Namespace, es.id.Name, es.container)
 }
 
+// podSource is an eventSource along with the labels of its pod.
+type podSource struct {
+	eventSource
+	labels map[string]string
+}
+
+func (ps podSource) Labels() map[string]string {
+	return ps.labels
+}
+
 type Event interface {
 	Source() EventSource
 	Log() []byte
+
+	// Time returns the timestamp kubelet recorded for the log line.
+	// It is the zero time if the line carried no timestamp.
+	Time() time.Time
+
+	// ContextBreak reports whether lines were skipped between this
+	// event and the previous event delivered from the same source.
+	// Only set when context lines are requested with WithLineContext.
+	ContextBreak() bool
+
+	// Level returns the level of a structured log line.
+	// See MessageLevel.
+	Level() Level
+
+	// Fields returns the fields of a structured log line, or nil if
+	// the line is unstructured.  See ParseMessage.  The fields are
+	// shared and must not be modified.
+	Fields() map[string]interface{}
+
+	// Partial reports whether the event holds only part of a log line
+	// that exceeded the maximum line size: a segment of a line that was
+	// split, or the beginning of a truncated line.  Partial lines are
+	// not parsed for structured fields.
+	Partial() bool
+
+	// Meta returns the kind of event for events reporting on the
+	// source, such as a container terminating, whose Log holds a
+	// description.  It is MetaNone for log lines.
+	Meta() MetaKind
+}
+
+func newEvent(source EventSource, log []byte, t time.Time) Event {
+	return &event{source: source, log: log, time: t}
 }
 
-func newEvent(source EventSource, log []byte) Event {
-	return &event{source, log}
+func newPartialEvent(source EventSource, log []byte, t time.Time) Event {
+	return &event{source: source, log: log, time: t, partial: true}
 }
 
 type event struct {
 	source EventSource
 	log    []byte
+	time   time.Time
+
+	partial bool
+
+	// structured fields of the log line, parsed on first use.
+	parseOnce sync.Once
+	fields    map[string]interface{}
+	level     Level
 }
 
 func (e *event) Source() EventSource {
@@ -61,3 +134,49 @@ func (e *event) Source() EventSource {
 func (e *event) Log() []byte {
 	return e.log
 }
+
+func (e *event) Time() time.Time {
+	return e.time
+}
+
+func (e *event) ContextBreak() bool {
+	return false
+}
+
+func (e *event) Meta() MetaKind {
+	return MetaNone
+}
+
+func (e *event) Partial() bool {
+	return e.partial
+}
+
+func (e *event) Level() Level {
+	e.parse()
+	return e.level
+}
+
+func (e *event) Fields() map[string]interface{} {
+	e.parse()
+	return e.fields
+}
+
+func (e *event) parse() {
+	e.parseOnce.Do(func() {
+		if e.partial {
+			return
+		}
+		if fields, ok := ParseMessage(e.log); ok {
+			e.fields = fields
+			e.level = MessageLevel(fields)
+		}
+	})
+}
+
+type contextBreakEvent struct {
+	Event
+}
+
+func (contextBreakEvent) ContextBreak() bool {
+	return true
+}
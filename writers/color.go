@@ -0,0 +1,140 @@
+package writers
+
+import (
+	"hash/fnv"
+	"io"
+	"os"
+
+	"github.com/boz/kail"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+type ColorMode string
+
+const (
+	// ColorAuto colors output if it is written to a terminal.
+	ColorAuto   ColorMode = "auto"
+	ColorAlways ColorMode = "always"
+	ColorNever  ColorMode = "never"
+)
+
+// ColorBy selects the part of an event's source that its color is chosen by.
+type ColorBy string
+
+const (
+	ColorByPod       ColorBy = "pod"
+	ColorByContainer ColorBy = "container"
+	ColorByNamespace ColorBy = "namespace"
+	ColorByNode      ColorBy = "node"
+	ColorByNone      ColorBy = "none"
+)
+
+var (
+	prefixAttributes = []color.Attribute{color.FgHiWhite, color.Bold}
+
+	sourceAttributes = []color.Attribute{
+		color.FgRed,
+		color.FgGreen,
+		color.FgYellow,
+		color.FgBlue,
+		color.FgMagenta,
+		color.FgCyan,
+		color.FgHiRed,
+		color.FgHiGreen,
+		color.FgHiYellow,
+		color.FgHiBlue,
+		color.FgHiMagenta,
+		color.FgHiCyan,
+	}
+)
+
+type colors struct {
+	enabled bool
+	by      ColorBy
+	prefix  *color.Color
+	palette []*color.Color
+
+	metaInfo  *color.Color
+	metaWarn  *color.Color
+	metaError *color.Color
+}
+
+func newColors(out io.Writer, mode ColorMode, by ColorBy) colors {
+	c := colors{by: by}
+
+	switch mode {
+	case ColorAlways:
+		c.enabled = true
+	case ColorNever:
+		c.enabled = false
+	default:
+		c.enabled = !color.NoColor && isTerminal(out)
+	}
+
+	c.prefix = c.newColor(prefixAttributes...)
+
+	c.metaInfo = c.newColor(color.Bold)
+	c.metaWarn = c.newColor(color.FgYellow, color.Bold)
+	c.metaError = c.newColor(color.FgRed, color.Bold)
+
+	c.palette = make([]*color.Color, 0, len(sourceAttributes))
+	for _, attr := range sourceAttributes {
+		c.palette = append(c.palette, c.newColor(attr, color.Bold))
+	}
+
+	return c
+}
+
+// forSource returns the color to display the event's prefix with.
+// Events with the same key always receive the same color.
+func (c colors) forSource(ev kail.Event) *color.Color {
+	var key string
+
+	switch c.by {
+	case ColorByPod:
+		key = ev.Source().Namespace() + "/" + ev.Source().Name()
+	case ColorByContainer:
+		key = ev.Source().Container()
+	case ColorByNamespace:
+		key = ev.Source().Namespace()
+	case ColorByNode:
+		key = ev.Source().Node()
+	default:
+		return c.prefix
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return c.palette[h.Sum32()%uint32(len(c.palette))]
+}
+
+// forMeta returns the color to display a meta event with.
+func (c colors) forMeta(ev kail.Event) *color.Color {
+	switch ev.Level() {
+	case kail.LevelError, kail.LevelFatal:
+		return c.metaError
+	case kail.LevelWarn:
+		return c.metaWarn
+	default:
+		return c.metaInfo
+	}
+}
+
+func (c colors) newColor(attrs ...color.Attribute) *color.Color {
+	clr := color.New(attrs...)
+	if c.enabled {
+		clr.EnableColor()
+	} else {
+		clr.DisableColor()
+	}
+	return clr
+}
+
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -1,18 +1,18 @@
 package writers
 
 import (
-	"fmt"
 	"io"
 
 	"github.com/boz/kail"
 )
 
-func NewWriter(out io.Writer) Writer {
-	return &writer{writerRaw{out}}
+func NewWriter(out io.Writer, opts ...Option) Writer {
+	return &writer{writerRaw{out}, newConfig(out, opts)}
 }
 
 type writer struct {
 	writerRaw
+	config config
 }
 
 func (w *writer) Print(ev kail.Event) error {
@@ -20,7 +20,17 @@ func (w *writer) Print(ev kail.Event) error {
 }
 
 func (w *writer) Fprint(out io.Writer, ev kail.Event) error {
-	prefix := w.prefix(ev)
+	prefix, err := w.config.prefix(ev)
+	if err != nil {
+		return err
+	}
+	prefixColor := w.config.colors.forSource(ev)
+
+	if ev.ContextBreak() {
+		if _, err := prefixColor.Fprintln(out, prefix+": --"); err != nil {
+			return err
+		}
+	}
 
 	if _, err := prefixColor.Fprint(out, prefix); err != nil {
 		return err
@@ -29,12 +39,10 @@ func (w *writer) Fprint(out io.Writer, ev kail.Event) error {
 		return err
 	}
 
-	return w.writerRaw.Fprint(out, ev)
-}
+	if ev.Meta() != kail.MetaNone {
+		_, err := w.config.colors.forMeta(ev).Fprintln(out, metaText(ev))
+		return err
+	}
 
-func (w *writer) prefix(ev kail.Event) string {
-	return fmt.Sprintf("%v/%v[%v]",
-		ev.Source().Namespace(),
-		ev.Source().Name(),
-		ev.Source().Container())
+	return w.writerRaw.Fprint(out, ev)
 }
@@ -2,23 +2,88 @@ package writers
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/boz/kail"
 )
 
-func NewJSONWriter(out io.Writer) Writer {
+// Field selects a value of the JSON output by its path, such as
+// message.req.id, and names it in the output.
+type Field struct {
+	Path string
+	Name string
+}
+
+// ParseFields parses a comma separated list of fields for WithFields.
+// Each field is a path, optionally followed by "as" and a name:
+//
+//	namespace, message.req.id as reqid, message.msg as msg
+//
+// Fields are named by their path unless renamed.
+func ParseFields(spec string) ([]Field, error) {
+	var fields []Field
+	for _, item := range strings.Split(spec, ",") {
+		words := strings.Fields(item)
+		switch {
+		case len(words) == 1:
+			fields = append(fields, Field{words[0], words[0]})
+		case len(words) == 3 && strings.EqualFold(words[1], "as"):
+			fields = append(fields, Field{words[0], words[2]})
+		default:
+			return nil, fmt.Errorf("invalid field: '%v'", strings.TrimSpace(item))
+		}
+	}
+	return fields, nil
+}
+
+// WithFields limits the JSON output to the given fields.  Paths refer
+// to the keys of the output: namespace, name, container, node, labels,
+// timestamp, previous, partial, meta, level and message.  Fields are included when
+// selected even if not enabled by WithNode or WithLabels.
+func WithFields(fields ...Field) Option {
+	return func(c *config) {
+		c.fields = append(c.fields, fields...)
+	}
+}
+
+// WithFlatten flattens nested objects of the JSON output into
+// top-level keys joined by dots, as in "message.req.id".
+func WithFlatten() Option {
+	return func(c *config) {
+		c.flatten = true
+	}
+}
+
+// WithNode includes the node of the pod in the JSON output.
+func WithNode() Option {
+	return func(c *config) {
+		c.node = true
+	}
+}
+
+// WithLabels includes the labels of the pod in the JSON output.
+func WithLabels() Option {
+	return func(c *config) {
+		c.labels = true
+	}
+}
+
+func NewJSONWriter(out io.Writer, opts ...Option) Writer {
 	return &writerJSON{
-		out: out,
+		out:    out,
+		config: newConfig(out, opts),
 		getEnc: func(o io.Writer) *json.Encoder {
 			return json.NewEncoder(o)
 		},
 	}
 }
 
-func NewJSONPrettyWriter(out io.Writer) Writer {
+func NewJSONPrettyWriter(out io.Writer, opts ...Option) Writer {
 	return &writerJSON{
-		out: out,
+		out:    out,
+		config: newConfig(out, opts),
 		getEnc: func(o io.Writer) *json.Encoder {
 			e := json.NewEncoder(o)
 			e.SetIndent("", "  ")
@@ -29,6 +94,7 @@ func NewJSONPrettyWriter(out io.Writer) Writer {
 
 type writerJSON struct {
 	out    io.Writer
+	config config
 	getEnc func(io.Writer) *json.Encoder
 }
 
@@ -38,10 +104,7 @@ func (w *writerJSON) Print(ev kail.Event) error {
 
 func (w *writerJSON) Fprint(out io.Writer, ev kail.Event) error {
 
-	log := ev.Log()
-	if sz := len(log); sz == 0 || log[sz-1] == byte('\n') {
-		log = log[:sz-1]
-	}
+	log := trimNewline(ev.Log())
 
 	enc := w.getEnc(out)
 
@@ -51,11 +114,54 @@ func (w *writerJSON) Fprint(out io.Writer, ev kail.Event) error {
 		"container": ev.Source().Container(),
 	}
 
-	messageMap := map[string]interface{}{}
-	if err := json.Unmarshal(log, &messageMap); err != nil {
-		data["message"] = string(log)
+	if node := ev.Source().Node(); node != "" && (w.config.node || w.config.fields != nil) {
+		data["node"] = node
+	}
+
+	if labels := ev.Source().Labels(); labels != nil && (w.config.labels || w.config.fields != nil) {
+		fields := make(map[string]interface{}, len(labels))
+		for key, value := range labels {
+			fields[key] = value
+		}
+		data["labels"] = fields
+	}
+
+	if t := ev.Time(); !t.IsZero() {
+		data["timestamp"] = t.Format(timestampFormat)
+	}
+
+	if ev.Source().Previous() {
+		data["previous"] = true
+	}
+
+	if level := ev.Level(); level != kail.LevelUnknown {
+		data["level"] = level.String()
+	}
+
+	if ev.Partial() {
+		data["partial"] = true
+	}
+
+	if kind := ev.Meta(); kind != kail.MetaNone {
+		data["meta"] = kind.String()
+	}
+
+	if message := ev.Fields(); message != nil {
+		data["message"] = message
 	} else {
-		data["message"] = messageMap
+		data["message"] = string(log)
+	}
+
+	if w.config.fields != nil {
+		data = projectFields(data, w.config.fields)
+	}
+
+	if w.config.flatten {
+		flat := make(map[string]interface{}, len(data))
+		for key, value := range data {
+			flattenValue(flat, key, value)
+		}
+		data = flat
 	}
 
 	if err := enc.Encode(data); err != nil {
@@ -63,3 +169,35 @@ func (w *writerJSON) Fprint(out io.Writer, ev kail.Event) error {
 	}
 	return nil
 }
+
+// projectFields returns the selected fields of data.
+// Fields which are not present are omitted.
+func projectFields(data map[string]interface{}, fields []Field) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := kail.LookupField(data, field.Path); ok {
+			projected[field.Name] = value
+		}
+	}
+	return projected
+}
+
+// flattenValue adds value to flat under key, adding the
+// fields of nested objects under dotted keys.
+func flattenValue(flat map[string]interface{}, key string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			flattenValue(flat, key+"."+k, v)
+		}
+	default:
+		flat[key] = value
+	}
+}
+
+func trimNewline(log []byte) []byte {
+	if sz := len(log); sz > 0 && log[sz-1] == byte('\n') {
+		return log[:sz-1]
+	}
+	return log
+}
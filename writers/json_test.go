@@ -0,0 +1,136 @@
+package writers
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		spec   string
+		fields []Field
+		err    bool
+	}{
+		{spec: "namespace", fields: []Field{{"namespace", "namespace"}}},
+		{
+			spec: " namespace , message.req.id as reqid,message.msg AS msg ",
+			fields: []Field{
+				{"namespace", "namespace"},
+				{"message.req.id", "reqid"},
+				{"message.msg", "msg"},
+			},
+		},
+		{spec: "", err: true},
+		{spec: "namespace,", err: true},
+		{spec: "message.req.id reqid", err: true},
+		{spec: "message.req.id as", err: true},
+		{spec: "message.req.id to reqid", err: true},
+	}
+
+	for _, test := range tests {
+		fields, err := ParseFields(test.spec)
+		if test.err {
+			assert.Error(t, err, "%q", test.spec)
+			continue
+		}
+		if assert.NoError(t, err, "%q", test.spec) {
+			assert.Equal(t, test.fields, fields, "%q", test.spec)
+		}
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	structured := testEvent{
+		log:    `{"msg":"done","req":{"id":"abc","status":200}}`,
+		time:   ts,
+		node:   "node-1",
+		labels: map[string]string{"app": "web"},
+	}
+
+	tests := []struct {
+		name     string
+		ev       testEvent
+		opts     []Option
+		expected map[string]interface{}
+	}{
+		{
+			name: "plain",
+			ev:   testEvent{log: "hello\n", node: "node-1"},
+			expected: map[string]interface{}{
+				"namespace": "ns", "name": "pod", "container": "app", "message": "hello",
+			},
+		},
+		{
+			name: "node and labels",
+			ev:   testEvent{log: "hello", node: "node-1", labels: map[string]string{"app": "web"}},
+			opts: []Option{WithNode(), WithLabels()},
+			expected: map[string]interface{}{
+				"namespace": "ns", "name": "pod", "container": "app", "message": "hello",
+				"node": "node-1", "labels": map[string]interface{}{"app": "web"},
+			},
+		},
+		{
+			name: "partial",
+			ev:   testEvent{log: `{"msg":`, partial: true},
+			expected: map[string]interface{}{
+				"namespace": "ns", "name": "pod", "container": "app", "message": `{"msg":`, "partial": true,
+			},
+		},
+		{
+			name: "fields",
+			ev:   structured,
+			opts: []Option{WithFields(
+				Field{"timestamp", "timestamp"},
+				Field{"name", "pod"},
+				Field{"labels.app", "app"},
+				Field{"node", "node"},
+				Field{"message.req.id", "reqid"},
+				Field{"message.missing", "missing"},
+				Field{"level", "level"},
+			)},
+			expected: map[string]interface{}{
+				"timestamp": "2024-01-02T03:04:05.000000000Z", "pod": "pod", "app": "web", "node": "node-1", "reqid": "abc",
+			},
+		},
+		{
+			name: "nested field",
+			ev:   structured,
+			opts: []Option{WithFields(Field{"message.req", "req"})},
+			expected: map[string]interface{}{
+				"req": map[string]interface{}{"id": "abc", "status": float64(200)},
+			},
+		},
+		{
+			name: "flatten",
+			ev:   structured,
+			opts: []Option{WithFlatten()},
+			expected: map[string]interface{}{
+				"namespace": "ns", "name": "pod", "container": "app", "timestamp": "2024-01-02T03:04:05.000000000Z",
+				"message.msg": "done", "message.req.id": "abc", "message.req.status": float64(200),
+			},
+		},
+		{
+			name: "flatten fields",
+			ev:   structured,
+			opts: []Option{WithFields(Field{"message.req", "req"}, Field{"name", "pod"}), WithFlatten()},
+			expected: map[string]interface{}{
+				"pod": "pod", "req.id": "abc", "req.status": float64(200),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		require.NoError(t, NewJSONWriter(buf, test.opts...).Print(test.ev), test.name)
+
+		var actual map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &actual), test.name)
+		assert.Equal(t, test.expected, actual, test.name)
+	}
+}
@@ -0,0 +1,138 @@
+package writers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/boz/kail"
+)
+
+func NewLogfmtWriter(out io.Writer) Writer {
+	return &writerLogfmt{out}
+}
+
+type writerLogfmt struct {
+	out io.Writer
+}
+
+func (w *writerLogfmt) Print(ev kail.Event) error {
+	return w.Fprint(w.out, ev)
+}
+
+func (w *writerLogfmt) Fprint(out io.Writer, ev kail.Event) error {
+	buf := new(bytes.Buffer)
+
+	writeLogfmtPair(buf, "namespace", ev.Source().Namespace())
+	writeLogfmtPair(buf, "pod", ev.Source().Name())
+	writeLogfmtPair(buf, "container", ev.Source().Container())
+	writeLogfmtPair(buf, "node", ev.Source().Node())
+
+	if t := ev.Time(); !t.IsZero() {
+		writeLogfmtPair(buf, "timestamp", t.Format(timestampFormat))
+	}
+
+	if ev.Source().Previous() {
+		writeLogfmtPair(buf, "previous", "true")
+	}
+
+	if ev.Partial() {
+		writeLogfmtPair(buf, "partial", "true")
+	}
+
+	if kind := ev.Meta(); kind != kail.MetaNone {
+		writeLogfmtPair(buf, "meta", kind.String())
+	}
+
+	log := bytes.TrimSpace(trimNewline(ev.Log()))
+
+	switch message := ev.Fields(); {
+	case message == nil:
+		writeLogfmtPair(buf, "msg", string(log))
+	case log[0] == '{':
+		keys := make([]string, 0, len(message))
+		for key := range message {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeLogfmtPair(buf, logfmtKey(key), logfmtValue(message[key]))
+		}
+	default:
+		// already logfmt; keep the original order and formatting.
+		buf.WriteByte(' ')
+		buf.Write(log)
+	}
+
+	buf.WriteByte('\n')
+
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	writeLogfmtValue(buf, value)
+}
+
+// logfmtKey replaces the characters of key that
+// are not allowed in logfmt keys with underscores.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(c rune) rune {
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || !unicode.IsPrint(c) {
+			return '_'
+		}
+		return c
+	}, key)
+}
+
+func writeLogfmtValue(buf *bytes.Buffer, value string) {
+	if needsLogfmtQuote(value) {
+		buf.WriteString(strconv.Quote(value))
+	} else {
+		buf.WriteString(value)
+	}
+}
+
+func logfmtValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		buf, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(buf)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func needsLogfmtQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, c := range value {
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || !unicode.IsPrint(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package writers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtWriter(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		ev       testEvent
+		expected string
+	}{
+		{
+			ev:       testEvent{log: "hello world\n"},
+			expected: `namespace=ns pod=pod container=app node="" msg="hello world"`,
+		},
+		{
+			ev:       testEvent{log: "hello", node: "node-1", time: ts},
+			expected: `namespace=ns pod=pod container=app node=node-1 timestamp=2024-01-02T03:04:05.000000000Z msg=hello`,
+		},
+		{
+			ev:       testEvent{log: `  level=info msg="request complete"  status=200 `},
+			expected: `namespace=ns pod=pod container=app node="" level=info msg="request complete"  status=200`,
+		},
+		{
+			ev:       testEvent{log: `{"msg":"done","status":200,"req":{"id":"abc"},"ok":true,"err":null}`},
+			expected: `namespace=ns pod=pod container=app node="" err="" msg=done ok=true req="{\"id\":\"abc\"}" status=200`,
+		},
+		{
+			ev:       testEvent{log: `{"a key":1,"a=b":2,"\"q\"":3,"":4}`},
+			expected: `namespace=ns pod=pod container=app node="" _=4 _q_=3 a_key=1 a_b=2`,
+		},
+		{
+			ev:       testEvent{log: `level=info msg=`, partial: true},
+			expected: `namespace=ns pod=pod container=app node="" partial=true msg="level=info msg="`,
+		},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		require.NoError(t, NewLogfmtWriter(buf).Print(test.ev))
+		assert.Equal(t, test.expected+"\n", buf.String(), test.ev.log)
+	}
+}
@@ -0,0 +1,206 @@
+package writers
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boz/kail"
+	"github.com/fatih/color"
+)
+
+var (
+	defaultTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp"}
+	defaultMessageKeys = []string{"msg", "message", "@message"}
+	defaultErrorKeys   = []string{"error", "err", "exception"}
+
+	levelAbbreviations = map[kail.Level]string{
+		kail.LevelTrace: "TRC",
+		kail.LevelDebug: "DBG",
+		kail.LevelInfo:  "INF",
+		kail.LevelWarn:  "WRN",
+		kail.LevelError: "ERR",
+		kail.LevelFatal: "FTL",
+	}
+)
+
+// WithLevelKeys adds keys to look for the level of structured messages
+// under.  They take precedence over the keys of common logging libraries.
+// Only the pretty writer uses them; Event.Level uses kail.LevelKeys.
+func WithLevelKeys(keys ...string) Option {
+	return func(c *config) {
+		c.levelKeys = append(c.levelKeys, keys...)
+	}
+}
+
+// WithTimeKeys adds keys to look for the time of structured messages under.
+func WithTimeKeys(keys ...string) Option {
+	return func(c *config) {
+		c.timeKeys = append(c.timeKeys, keys...)
+	}
+}
+
+// WithMessageKeys adds keys to look for the text of structured messages under.
+func WithMessageKeys(keys ...string) Option {
+	return func(c *config) {
+		c.messageKeys = append(c.messageKeys, keys...)
+	}
+}
+
+// WithErrorKeys adds keys to look for errors of structured messages under.
+func WithErrorKeys(keys ...string) Option {
+	return func(c *config) {
+		c.errorKeys = append(c.errorKeys, keys...)
+	}
+}
+
+// NewPrettyWriter returns a writer that renders structured log lines
+// for the console: time, level and message first, followed by the
+// remaining fields.  Unstructured lines are written as-is.
+func NewPrettyWriter(out io.Writer, opts ...Option) Writer {
+	c := newConfig(out, opts)
+	return &writerPretty{
+		writer:      writer{writerRaw{out}, c},
+		levelKeys:   append(c.levelKeys, kail.LevelKeys()...),
+		timeKeys:    append(c.timeKeys, defaultTimeKeys...),
+		messageKeys: append(c.messageKeys, defaultMessageKeys...),
+		errorKeys:   append(c.errorKeys, defaultErrorKeys...),
+		keyColor:    c.colors.newColor(color.FgHiBlack),
+		errorColor:  c.colors.newColor(color.FgRed),
+		levelColors: map[kail.Level]*color.Color{
+			kail.LevelUnknown: c.colors.newColor(color.Bold),
+			kail.LevelTrace:   c.colors.newColor(color.FgHiBlack),
+			kail.LevelDebug:   c.colors.newColor(color.FgBlue),
+			kail.LevelInfo:    c.colors.newColor(color.FgGreen),
+			kail.LevelWarn:    c.colors.newColor(color.FgYellow),
+			kail.LevelError:   c.colors.newColor(color.FgRed),
+			kail.LevelFatal:   c.colors.newColor(color.FgRed, color.Bold),
+		},
+	}
+}
+
+type writerPretty struct {
+	writer
+
+	levelKeys   []string
+	timeKeys    []string
+	messageKeys []string
+	errorKeys   []string
+
+	keyColor    *color.Color
+	errorColor  *color.Color
+	levelColors map[kail.Level]*color.Color
+}
+
+func (w *writerPretty) Print(ev kail.Event) error {
+	return w.Fprint(w.out, ev)
+}
+
+func (w *writerPretty) Fprint(out io.Writer, ev kail.Event) error {
+	message := ev.Fields()
+	if message == nil {
+		return w.writer.Fprint(out, ev)
+	}
+
+	// copy the fields, which are removed as they are displayed.
+	fields := make(map[string]interface{}, len(message))
+	for key, value := range message {
+		fields[key] = value
+	}
+
+	prefix, err := w.config.prefix(ev)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+
+	w.config.colors.forSource(ev).Fprint(buf, prefix, ": ")
+
+	// separate the parts that are present with single spaces.
+	start := buf.Len()
+	separate := func() {
+		if buf.Len() > start {
+			buf.WriteByte(' ')
+		}
+	}
+
+	if _, value, ok := takeField(fields, w.timeKeys); ok {
+		w.keyColor.Fprint(buf, prettyTime(value))
+	}
+
+	if _, value, ok := takeField(fields, w.levelKeys); ok {
+		level := kail.LevelOf(value)
+		name, ok := levelAbbreviations[level]
+		if !ok {
+			name = strings.ToUpper(logfmtValue(value))
+		}
+		separate()
+		w.levelColors[level].Fprint(buf, name)
+	}
+
+	if _, value, ok := takeField(fields, w.messageKeys); ok {
+		if message := logfmtValue(value); message != "" {
+			separate()
+			buf.WriteString(message)
+		}
+	}
+
+	errKey, errValue, hasErr := takeField(fields, w.errorKeys)
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		separate()
+		w.keyColor.Fprint(buf, key, "=")
+		writeLogfmtValue(buf, logfmtValue(fields[key]))
+	}
+
+	if hasErr {
+		value := new(bytes.Buffer)
+		writeLogfmtPair(value, errKey, logfmtValue(errValue))
+		separate()
+		w.errorColor.Fprint(buf, value.String())
+	}
+
+	buf.WriteByte('\n')
+
+	_, err = out.Write(buf.Bytes())
+	return err
+}
+
+// takeField removes and returns the first of keys present in fields.
+func takeField(fields map[string]interface{}, keys []string) (string, interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			delete(fields, key)
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// prettyTime formats times given as unix seconds or milliseconds.
+// other values are displayed as-is.
+func prettyTime(value interface{}) string {
+	n, ok := value.(float64)
+	if !ok {
+		return logfmtValue(value)
+	}
+
+	var t time.Time
+	if n > 1e11 {
+		t = time.UnixMilli(int64(n))
+	} else {
+		secs, frac := math.Modf(n)
+		t = time.Unix(int64(secs), int64(frac*1e9))
+	}
+	return t.UTC().Format(timestampFormat)
+}
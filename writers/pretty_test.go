@@ -0,0 +1,62 @@
+package writers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrettyWriter(t *testing.T) {
+	tests := []struct {
+		log      string
+		opts     []Option
+		expected string
+	}{
+		{
+			log:      "hello world",
+			expected: "hello world",
+		},
+		{
+			log:      `{"level":"info","ts":1704164645.5,"msg":"started","port":8080,"caller":"main.go:10"}`,
+			expected: "2024-01-02T03:04:05.500000000Z INF started caller=main.go:10 port=8080",
+		},
+		{
+			log:      `{"level":30,"time":1704164645123,"msg":"pino"}`,
+			expected: "2024-01-02T03:04:05.123000000Z INF pino",
+		},
+		{
+			log:      `time="2024-01-02T03:04:05Z" level=warning msg="slow request" err=boom dur=5s`,
+			expected: "2024-01-02T03:04:05Z WRN slow request dur=5s err=boom",
+		},
+		{
+			log:      `{"severity":"audit","message":"hi there"}`,
+			expected: "AUDIT hi there",
+		},
+		{
+			log:      `{"sev":"ERROR","text":"bad","level":"x","exception":"trace"}`,
+			opts:     []Option{WithLevelKeys("sev"), WithMessageKeys("text"), WithErrorKeys("exception")},
+			expected: "ERR bad level=x exception=trace",
+		},
+		{
+			log:      `{"count":2}`,
+			expected: "count=2",
+		},
+		{
+			log:      `{"level":"debug","err":"boom"}`,
+			expected: "DBG err=boom",
+		},
+		{
+			log:      `{"time":"2024-01-02T03:04:05Z","msg":""}`,
+			expected: "2024-01-02T03:04:05Z",
+		},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		opts := append([]Option{WithColor(ColorNever)}, test.opts...)
+		require.NoError(t, NewPrettyWriter(buf, opts...).Print(testEvent{log: test.log}))
+		assert.Equal(t, "ns/pod[app]: "+test.expected+"\n", buf.String(), test.log)
+	}
+}
@@ -1,6 +1,7 @@
 package writers
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/boz/kail"
@@ -19,6 +20,13 @@ func (w *writerRaw) Print(ev kail.Event) error {
 }
 
 func (w *writerRaw) Fprint(out io.Writer, ev kail.Event) error {
+	if ev.Meta() != kail.MetaNone {
+		// there is no prefix to identify the source by.
+		_, err := fmt.Fprintf(out, "[kail] %v/%v[%v] %v: %s\n",
+			ev.Source().Namespace(), ev.Source().Name(), ev.Source().Container(), ev.Meta(), ev.Log())
+		return err
+	}
+
 	log := ev.Log()
 
 	if _, err := out.Write(log); err != nil {
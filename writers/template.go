@@ -0,0 +1,78 @@
+package writers
+
+import (
+	"bytes"
+	"io"
+	"text/template"
+
+	"github.com/boz/kail"
+)
+
+// ParseTemplate parses a template for NewTemplateWriter or WithPrefixTemplate.
+//
+// Templates are executed with a map holding the event's Namespace, Pod,
+// Container, Node, Time, Previous, Partial, Meta, Level and Log.  If the
+// log line is structured (see kail.ParseMessage), its fields are available
+// under message, as in {{.message.level}}.  Level is the name of the
+// level, empty if unknown.  Meta is the kind of meta events, whose Log
+// describes the event, and empty for log lines.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("kail").Parse(text)
+}
+
+func NewTemplateWriter(out io.Writer, tmpl *template.Template) Writer {
+	return &writerTemplate{out, tmpl}
+}
+
+type writerTemplate struct {
+	out  io.Writer
+	tmpl *template.Template
+}
+
+func (w *writerTemplate) Print(ev kail.Event) error {
+	return w.Fprint(w.out, ev)
+}
+
+func (w *writerTemplate) Fprint(out io.Writer, ev kail.Event) error {
+	buf, err := executeTemplate(w.tmpl, ev)
+	if err != nil {
+		return err
+	}
+
+	if sz := len(buf); sz == 0 || buf[sz-1] != byte('\n') {
+		buf = append(buf, '\n')
+	}
+
+	_, err = out.Write(buf)
+	return err
+}
+
+func executeTemplate(tmpl *template.Template, ev kail.Event) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, templateData(ev)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func templateData(ev kail.Event) map[string]interface{} {
+	log := trimNewline(ev.Log())
+
+	// message is a nil map for unstructured lines so that
+	// fields can be referenced without failing.
+	message := ev.Fields()
+
+	return map[string]interface{}{
+		"Namespace": ev.Source().Namespace(),
+		"Pod":       ev.Source().Name(),
+		"Container": ev.Source().Container(),
+		"Node":      ev.Source().Node(),
+		"Time":      ev.Time(),
+		"Previous":  ev.Source().Previous(),
+		"Partial":   ev.Partial(),
+		"Meta":      ev.Meta().String(),
+		"Level":     ev.Level().String(),
+		"Log":       string(log),
+		"message":   message,
+	}
+}
@@ -0,0 +1,65 @@
+package writers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateWriter(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		tmpl     string
+		ev       testEvent
+		expected string
+	}{
+		{
+			tmpl:     "{{.Time}} {{.Pod}} {{.message.level}} {{.message.msg}}",
+			ev:       testEvent{log: `{"level":"info","msg":"started"}`, time: ts},
+			expected: "2024-01-02 03:04:05 +0000 UTC pod info started",
+		},
+		{
+			tmpl:     "{{.Level}} {{.message.msg}}",
+			ev:       testEvent{log: `level=warning msg="slow request"`},
+			expected: "warn slow request",
+		},
+		{
+			tmpl:     "{{.Namespace}}/{{.Container}} {{with .message}}{{.msg}}{{else}}{{.Log}}{{end}}",
+			ev:       testEvent{log: "hello world\n"},
+			expected: "ns/app hello world",
+		},
+		{
+			tmpl:     "[{{.Level}}] {{.message.msg}}",
+			ev:       testEvent{log: "hello world"},
+			expected: "[] <no value>",
+		},
+	}
+
+	for _, test := range tests {
+		tmpl, err := ParseTemplate(test.tmpl)
+		require.NoError(t, err)
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, NewTemplateWriter(buf, tmpl).Print(test.ev))
+		assert.Equal(t, test.expected+"\n", buf.String(), test.tmpl)
+	}
+}
+
+func TestParseTemplateError(t *testing.T) {
+	_, err := ParseTemplate("{{.Pod")
+	assert.Error(t, err)
+}
+
+func TestPrefixTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Pod}}/{{.Container}}")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	writer := NewWriter(buf, WithColor(ColorNever), WithPrefixTemplate(tmpl))
+	require.NoError(t, writer.Print(testEvent{log: "hello world\n"}))
+	assert.Equal(t, "pod/app: hello world\n", buf.String())
+}
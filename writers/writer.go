@@ -1,17 +1,117 @@
 package writers
 
 import (
+	"fmt"
 	"io"
+	"text/template"
 
 	"github.com/boz/kail"
-	"github.com/fatih/color"
 )
 
-var (
-	prefixColor = color.New(color.FgHiWhite, color.Bold)
+const (
+	// RFC3339 with fixed-width nanoseconds, as emitted by kubelet.
+	timestampFormat = "2006-01-02T15:04:05.000000000Z07:00"
 )
 
 type Writer interface {
 	Print(event kail.Event) error
 	Fprint(w io.Writer, event kail.Event) error
 }
+
+type Option func(*config)
+
+type config struct {
+	timestamps bool
+	prefixTmpl *template.Template
+	colorMode  ColorMode
+	colorBy    ColorBy
+	colors     colors
+
+	levelKeys   []string
+	timeKeys    []string
+	messageKeys []string
+	errorKeys   []string
+
+	fields  []Field
+	flatten bool
+	node    bool
+	labels  bool
+}
+
+// WithTimestamps prefixes each line with the time it was logged.
+func WithTimestamps() Option {
+	return func(c *config) {
+		c.timestamps = true
+	}
+}
+
+// WithPrefixTemplate replaces the default line prefix
+// with the output of the given template.  See ParseTemplate.
+func WithPrefixTemplate(tmpl *template.Template) Option {
+	return func(c *config) {
+		c.prefixTmpl = tmpl
+	}
+}
+
+// WithColor sets whether output is colored.  Defaults to ColorAuto.
+func WithColor(mode ColorMode) Option {
+	return func(c *config) {
+		c.colorMode = mode
+	}
+}
+
+// WithColorBy sets how colors are assigned to event sources.
+// Defaults to ColorByPod.
+func WithColorBy(by ColorBy) Option {
+	return func(c *config) {
+		c.colorBy = by
+	}
+}
+
+func newConfig(out io.Writer, opts []Option) config {
+	c := config{
+		colorMode: ColorAuto,
+		colorBy:   ColorByPod,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	c.colors = newColors(out, c.colorMode, c.colorBy)
+	return c
+}
+
+// metaText is the text displayed for meta events.
+func metaText(ev kail.Event) string {
+	return fmt.Sprintf("[kail] %v: %s", ev.Meta(), ev.Log())
+}
+
+func (c config) prefix(ev kail.Event) (string, error) {
+	if c.prefixTmpl != nil {
+		prefix, err := executeTemplate(c.prefixTmpl, ev)
+		return string(prefix), err
+	}
+
+	prefix := fmt.Sprintf("%v/%v[%v]",
+		ev.Source().Namespace(),
+		ev.Source().Name(),
+		ev.Source().Container())
+
+	// meta events about a pod have no container.
+	if ev.Source().Container() == "" {
+		prefix = ev.Source().Namespace() + "/" + ev.Source().Name()
+	}
+
+	if ev.Source().Previous() {
+		prefix += " (previous)"
+	}
+
+	if ev.Partial() {
+		prefix += " (partial)"
+	}
+
+	if t := ev.Time(); c.timestamps && !t.IsZero() {
+		prefix = t.Format(timestampFormat) + " " + prefix
+	}
+
+	return prefix, nil
+}
@@ -0,0 +1,46 @@
+package writers
+
+import (
+	"time"
+
+	"github.com/boz/kail"
+)
+
+// testEvent is a log line from a container of pod ns/pod.
+type testEvent struct {
+	log     string
+	time    time.Time
+	node    string
+	labels  map[string]string
+	partial bool
+}
+
+func (e testEvent) Source() kail.EventSource { return e }
+func (e testEvent) Namespace() string        { return "ns" }
+func (e testEvent) Name() string             { return "pod" }
+func (e testEvent) Container() string        { return "app" }
+func (e testEvent) Node() string             { return e.node }
+func (e testEvent) Previous() bool           { return false }
+func (e testEvent) Labels() map[string]string {
+	return e.labels
+}
+
+func (e testEvent) Log() []byte        { return []byte(e.log) }
+func (e testEvent) Time() time.Time    { return e.time }
+func (e testEvent) ContextBreak() bool { return false }
+func (e testEvent) Partial() bool      { return e.partial }
+func (e testEvent) Meta() kail.MetaKind {
+	return kail.MetaNone
+}
+
+func (e testEvent) Level() kail.Level {
+	return kail.MessageLevel(e.Fields())
+}
+
+func (e testEvent) Fields() map[string]interface{} {
+	if e.partial {
+		return nil
+	}
+	fields, _ := kail.ParseMessage([]byte(e.log))
+	return fields
+}
@@ -2,19 +2,19 @@ package writers
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 
 	"github.com/boz/kail"
 	"github.com/rs/zerolog"
 )
 
-func NewZerologWriter(out io.Writer) Writer {
-	return &zerologwriter{out}
+func NewZerologWriter(out io.Writer, opts ...Option) Writer {
+	return &zerologwriter{out, newConfig(out, opts)}
 }
 
 type zerologwriter struct {
-	out io.Writer
+	out    io.Writer
+	config config
 }
 
 func (w *zerologwriter) Print(ev kail.Event) error {
@@ -22,7 +22,11 @@ func (w *zerologwriter) Print(ev kail.Event) error {
 }
 
 func (w *zerologwriter) Fprint(out io.Writer, ev kail.Event) error {
-	prefix := w.prefix(ev)
+	prefix, err := w.config.prefix(ev)
+	if err != nil {
+		return err
+	}
+	prefixColor := w.config.colors.forSource(ev)
 
 	if _, err := prefixColor.Fprint(out, prefix); err != nil {
 		return err
@@ -31,12 +35,17 @@ func (w *zerologwriter) Fprint(out io.Writer, ev kail.Event) error {
 		return err
 	}
 
+	if ev.Meta() != kail.MetaNone {
+		_, err := w.config.colors.forMeta(ev).Fprintln(out, metaText(ev))
+		return err
+	}
+
 	log := ev.Log()
 
 	// Attempt to parse log as json
 	var v interface{}
 	if err := json.Unmarshal(log, &v); err == nil {
-		consoleWriter := zerolog.ConsoleWriter{Out: w.out}
+		consoleWriter := zerolog.ConsoleWriter{Out: w.out, NoColor: !w.config.colors.enabled}
 		if _, err := consoleWriter.Write(log); err != nil {
 			return err
 		}
@@ -55,10 +64,3 @@ func (w *zerologwriter) Fprint(out io.Writer, ev kail.Event) error {
 	}
 	return nil
 }
-
-func (w *zerologwriter) prefix(ev kail.Event) string {
-	return fmt.Sprintf("%v/%v[%v]",
-		ev.Source().Namespace(),
-		ev.Source().Name(),
-		ev.Source().Container())
-}